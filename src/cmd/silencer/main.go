@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/nwlunatic/prometheus-alertmanager-silencer/src/httpserver"
 	"github.com/nwlunatic/prometheus-alertmanager-silencer/src/signals"
-	"github.com/prometheus/alertmanager/cli"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	commoncfg "github.com/prometheus/common/config"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/alecthomas/kingpin.v2"
 
@@ -23,53 +30,176 @@ func main() {
 
 	cfg := parseFlags()
 
-	configFile, err := os.Open(cfg.configFile)
+	alertmanagerConfigs, err := loadAlertmanagerConfigs(cfg)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	yamlConfig, err := silencer.ParseYaml(configFile)
+	instance, err := silencer.NewInstance(cfg.instanceName)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	yamlMaintenanceIndex := silencer.BuildYamlMaintenanceIndex(yamlConfig.Maintenances)
+	retryPolicy := silencer.DefaultRetryPolicy()
+	retryPolicy.Timeout = cfg.alertManagerTimeout
+	retryPolicy.MaxAttempts = cfg.alertManagerMaxAttempts
+	retryPolicy.MaxElapsed = cfg.alertManagerRetryMaxElapsed
 
-	config, err := silencer.ConfigFromYaml(yamlConfig)
-	if err != nil {
-		logger.Fatal(err)
+	clock := silencer.Clock{}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	metrics := silencer.NewMetrics(registry)
+
+	alertmanagerNames := make([]string, 0, len(alertmanagerConfigs))
+	for name := range alertmanagerConfigs {
+		alertmanagerNames = append(alertmanagerNames, name)
+	}
+	sort.Strings(alertmanagerNames)
+
+	targets := make([]silencer.Target, 0, len(alertmanagerConfigs))
+	alertmanagersHealth := make(silencer.AlertmanagersHealth, 0, len(alertmanagerConfigs))
+	targetNames := make(map[string]struct{}, len(alertmanagerConfigs))
+	for _, name := range alertmanagerNames {
+		groupTargets, groupHealth, err := silencer.BuildTargets(
+			name,
+			alertmanagerConfigs[name],
+			retryPolicy,
+			metrics,
+			clock,
+			logger,
+		)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		for _, target := range groupTargets {
+			if _, ok := targetNames[target.Name()]; ok {
+				logger.Fatalf("duplicate alertmanager target %s", target.Name())
+			}
+			targetNames[target.Name()] = struct{}{}
+		}
+
+		targets = append(targets, groupTargets...)
+		alertmanagersHealth = append(alertmanagersHealth, groupHealth...)
 	}
 
-	u, err := url.ParseRequestURI(cfg.alertManagerURL)
+	stateStore, err := silencer.NewStateStore(cfg.storageType, cfg.storagePath)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	defer stateStore.Close()
+
+	history := silencer.NewHistory(filepath.Join(cfg.storagePath, "history.jsonl"), cfg.historyRetention, clock, logger)
+	history.Start()
 
-	clock := silencer.Clock{}
 	maintenanceService := silencer.NewMaintenanceService(
-		"maintenance service",
-		config.Maintenances,
-		silencer.NewActiveMaintenanceStorage(),
-		silencer.NewSilenceService(
-			cli.NewAlertmanagerClient(u).Silence,
-		),
+		instance,
+		nil,
+		cfg.silenceLookahead,
+		cfg.alertManagerOperationTimeout,
+		targets,
+		stateStore,
+		history,
 		clock,
 		logger,
 	)
+
+	yamlMaintenanceIndex := silencer.NewReloadableYamlMaintenanceIndex(silencer.YamlMaintenanceIndex{})
+	maintenanceSources := silencer.NewMaintenanceSources(
+		maintenanceService,
+		yamlMaintenanceIndex,
+		silencer.MaintenanceSourceFile,
+		silencer.MaintenanceSourceCalendar,
+		silencer.MaintenanceSourceAPI,
+	)
+	configReloader := silencer.NewConfigReloader(
+		cfg.configFile,
+		maintenanceSources,
+		metrics,
+		logger,
+	)
+	err = configReloader.Reload()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	runtimeMaintenances := silencer.NewRuntimeMaintenances(
+		stateStore,
+		maintenanceSources.Source(silencer.MaintenanceSourceAPI),
+		maintenanceService,
+		history,
+		logger,
+	)
+	err = runtimeMaintenances.Load()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	occurrenceExceptions := silencer.NewOccurrenceExceptions(
+		stateStore,
+		maintenanceService,
+		history,
+		clock,
+	)
+	err = occurrenceExceptions.Load()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	err = maintenanceService.Start()
 	if err != nil {
 		logger.Fatal(err)
 	}
 
+	configWatcher := silencer.NewConfigWatcher(configReloader, cfg.configWatchInterval, logger)
+	configWatcher.Start()
+
+	reconciler := silencer.NewReconciler(maintenanceService, cfg.reconcileInterval, metrics, logger)
+	registry.MustRegister(silencer.NewMaintenanceCollector(maintenanceService))
+	if cfg.exporterEnabled {
+		registry.MustRegister(silencer.NewExporterCollector(maintenanceService, cfg.exporterUpcoming, clock))
+	}
+	reconciler.Start()
+
+	reloadErrors := signals.BindReload(context.Background(), configReloader)
+	go func() {
+		for err := range reloadErrors {
+			logger.WithError(err).Error("failed to reload config")
+		}
+	}()
+
 	statusBoardHandler := silencer.NewStatusBoardHandler(
 		silencer.NewStatusBoard(
 			maintenanceService,
 			yamlMaintenanceIndex,
+			alertmanagersHealth,
+			clock,
 		),
 	)
 
 	r := chi.NewRouter()
 	r.Get("/", statusBoardHandler.Handle())
+	timelineHandler := silencer.NewTimelineHandler(maintenanceService, history, clock)
+	r.Get("/timeline", timelineHandler.HTML())
+	r.Get("/calendar.ics", silencer.NewCalendarHandler(maintenanceService, yamlMaintenanceIndex, clock).Handle())
+	r.Post("/-/reload", silencer.NewReloadHandler(configReloader).Handle())
+	// Alertmanager is called at least on every reconciliation, so the last successful call is expected within few of them
+	healthHandler := silencer.NewHealthHandler(maintenanceService, alertmanagersHealth, 3*cfg.reconcileInterval, clock)
+	r.Get("/-/healthy", healthHandler.Healthy())
+	r.Get("/-/ready", healthHandler.Ready())
+	r.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	r.Route("/api/v1", func(r chi.Router) {
+		silencer.NewMaintenanceAPIHandler(
+			maintenanceService,
+			yamlMaintenanceIndex,
+			runtimeMaintenances,
+			maintenanceService,
+			occurrenceExceptions,
+			clock,
+		).Register(r)
+		silencer.NewHistoryAPIHandler(history).Register(r)
+		timelineHandler.Register(r)
+	})
 
 	server := httpserver.NewServer(&http.Server{Addr: net.JoinHostPort("", "5000"), Handler: r})
 	serverErr := make(chan error)
@@ -78,7 +208,14 @@ func main() {
 		serverErr <- server.Start()
 	}()
 
-	gracefulStopErrors := signals.BindGracefulStop(context.Background(), server, maintenanceService)
+	gracefulStopErrors := signals.BindGracefulStop(
+		context.Background(),
+		server,
+		reconciler,
+		maintenanceService,
+		configWatcher,
+		history,
+	)
 	errChan := joinErrorChannels(serverErr, gracefulStopErrors)
 	for err := range errChan {
 		if err != nil {
@@ -109,10 +246,101 @@ func joinErrorChannels(in ...<-chan error) <-chan error {
 	return out
 }
 
+// loadAlertmanagerConfigs builds settings of default Alertmanager from flags, overridden by alertmanager section
+// of config file, and settings of named Alertmanagers from alertmanagers section
+func loadAlertmanagerConfigs(cfg *cliFlags) (map[string]silencer.AlertmanagerConfig, error) {
+	urls, err := silencer.ParseAlertmanagerURLs(cfg.alertManagerURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	httpConfig := commoncfg.DefaultHTTPClientConfig
+	if cfg.alertManagerUsername != "" {
+		httpConfig.BasicAuth = &commoncfg.BasicAuth{
+			Username:     cfg.alertManagerUsername,
+			Password:     commoncfg.Secret(cfg.alertManagerPassword),
+			PasswordFile: cfg.alertManagerPasswordFile,
+		}
+	}
+	httpConfig.BearerToken = commoncfg.Secret(cfg.alertManagerBearerToken)
+	httpConfig.BearerTokenFile = cfg.alertManagerBearerTokenFile
+	httpConfig.TLSConfig = commoncfg.TLSConfig{
+		CAFile:             cfg.alertManagerCAFile,
+		CertFile:           cfg.alertManagerCertFile,
+		KeyFile:            cfg.alertManagerKeyFile,
+		ServerName:         cfg.alertManagerServerName,
+		InsecureSkipVerify: cfg.alertManagerInsecureSkipVerify,
+	}
+	if cfg.alertManagerProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.alertManagerProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		httpConfig.ProxyURL = commoncfg.URL{URL: proxyURL}
+	}
+
+	headers := make(map[string]string, len(cfg.alertManagerHeaders))
+	for _, header := range cfg.alertManagerHeaders {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid header %q, expected Name: value", header)
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	alertmanagerConfig := silencer.AlertmanagerConfig{
+		URLs:        urls,
+		Mode:        cfg.alertManagerMode,
+		HTTPConfig:  httpConfig,
+		Headers:     headers,
+		ExternalURL: cfg.alertManagerExternalURL,
+	}
+
+	f, err := os.Open(cfg.configFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	yamlConfig, err := silencer.ParseYaml(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return silencer.AlertmanagerConfigs(alertmanagerConfig, yamlConfig, filepath.Dir(cfg.configFile))
+}
+
 // cliFlags is a union of the fields, which application could parse from CLI args
 type cliFlags struct {
-	configFile      string
-	alertManagerURL string
+	configFile                     string
+	configWatchInterval            time.Duration
+	alertManagerURLs               []string
+	alertManagerMode               string
+	alertManagerExternalURL        string
+	alertManagerTimeout            time.Duration
+	alertManagerMaxAttempts        int
+	alertManagerRetryMaxElapsed    time.Duration
+	alertManagerOperationTimeout   time.Duration
+	alertManagerUsername           string
+	alertManagerPassword           string
+	alertManagerPasswordFile       string
+	alertManagerBearerToken        string
+	alertManagerBearerTokenFile    string
+	alertManagerCAFile             string
+	alertManagerCertFile           string
+	alertManagerKeyFile            string
+	alertManagerServerName         string
+	alertManagerInsecureSkipVerify bool
+	alertManagerProxyURL           string
+	alertManagerHeaders            []string
+	instanceName                   string
+	reconcileInterval              time.Duration
+	silenceLookahead               time.Duration
+	exporterEnabled                bool
+	exporterUpcoming               time.Duration
+	storagePath                    string
+	storageType                    string
+	historyRetention               time.Duration
 }
 
 // parseFlags maps CLI flags to struct
@@ -124,10 +352,131 @@ func parseFlags() *cliFlags {
 		Default("silencer.yml").
 		StringVar(&cfg.configFile)
 
-	kingpin.Flag("alertmanager.url", "AlertManager url").
+	kingpin.Flag("config.watch-interval", "How often config file is checked for changes, 0 disables watching").
+		Envar("CONFIG_WATCH_INTERVAL").
+		Default("10s").
+		DurationVar(&cfg.configWatchInterval)
+
+	kingpin.Flag("alertmanager.url", "AlertManager url, repeatable for several instances").
 		Envar("ALERT_MANAGER_URL").
 		Default("http://localhost:9093").
-		StringVar(&cfg.alertManagerURL)
+		StringsVar(&cfg.alertManagerURLs)
+
+	kingpin.Flag("alertmanager.mode", "How several AlertManager instances are used: any for clustered peers, all for independent instances").
+		Envar("ALERT_MANAGER_MODE").
+		Default(silencer.AlertmanagerModeAny).
+		EnumVar(&cfg.alertManagerMode, silencer.AlertmanagerModeAny, silencer.AlertmanagerModeAll)
+
+	kingpin.Flag("alertmanager.external-url", "AlertManager UI silences are linked to on status board, url of instance by default").
+		Envar("ALERT_MANAGER_EXTERNAL_URL").
+		StringVar(&cfg.alertManagerExternalURL)
+
+	kingpin.Flag("alertmanager.timeout", "AlertManager call timeout").
+		Envar("ALERT_MANAGER_TIMEOUT").
+		Default("10s").
+		DurationVar(&cfg.alertManagerTimeout)
+
+	kingpin.Flag("alertmanager.max-attempts", "How many times failed AlertManager call is attempted, client errors are not retried").
+		Envar("ALERT_MANAGER_MAX_ATTEMPTS").
+		Default("5").
+		IntVar(&cfg.alertManagerMaxAttempts)
+
+	kingpin.Flag("alertmanager.retry-max-elapsed", "How long failed AlertManager call is retried, 0 retries till max-attempts").
+		Envar("ALERT_MANAGER_RETRY_MAX_ELAPSED").
+		Default("15s").
+		DurationVar(&cfg.alertManagerRetryMaxElapsed)
+
+	kingpin.Flag("alertmanager.operation-timeout", "How long AlertManager calls of a single operation, e.g. reconciliation, take at most, 0 disables it").
+		Envar("ALERT_MANAGER_OPERATION_TIMEOUT").
+		Default("30s").
+		DurationVar(&cfg.alertManagerOperationTimeout)
+
+	kingpin.Flag("alertmanager.basic-auth.username", "AlertManager basic auth username").
+		Envar("ALERT_MANAGER_BASIC_AUTH_USERNAME").
+		StringVar(&cfg.alertManagerUsername)
+
+	kingpin.Flag("alertmanager.basic-auth.password", "AlertManager basic auth password").
+		Envar("ALERT_MANAGER_BASIC_AUTH_PASSWORD").
+		StringVar(&cfg.alertManagerPassword)
+
+	kingpin.Flag("alertmanager.basic-auth.password-file", "File with AlertManager basic auth password").
+		Envar("ALERT_MANAGER_BASIC_AUTH_PASSWORD_FILE").
+		StringVar(&cfg.alertManagerPasswordFile)
+
+	kingpin.Flag("alertmanager.bearer-token", "AlertManager bearer token").
+		Envar("ALERT_MANAGER_BEARER_TOKEN").
+		StringVar(&cfg.alertManagerBearerToken)
+
+	kingpin.Flag("alertmanager.bearer-token-file", "File with AlertManager bearer token, re-read on every call").
+		Envar("ALERT_MANAGER_BEARER_TOKEN_FILE").
+		StringVar(&cfg.alertManagerBearerTokenFile)
+
+	kingpin.Flag("alertmanager.tls.ca-file", "CA certificate to verify AlertManager with").
+		Envar("ALERT_MANAGER_TLS_CA_FILE").
+		StringVar(&cfg.alertManagerCAFile)
+
+	kingpin.Flag("alertmanager.tls.cert-file", "Client certificate for AlertManager").
+		Envar("ALERT_MANAGER_TLS_CERT_FILE").
+		StringVar(&cfg.alertManagerCertFile)
+
+	kingpin.Flag("alertmanager.tls.key-file", "Client certificate key for AlertManager").
+		Envar("ALERT_MANAGER_TLS_KEY_FILE").
+		StringVar(&cfg.alertManagerKeyFile)
+
+	kingpin.Flag("alertmanager.tls.server-name", "Server name to verify AlertManager certificate with").
+		Envar("ALERT_MANAGER_TLS_SERVER_NAME").
+		StringVar(&cfg.alertManagerServerName)
+
+	kingpin.Flag("alertmanager.tls.insecure-skip-verify", "Skip AlertManager certificate verification").
+		Envar("ALERT_MANAGER_TLS_INSECURE_SKIP_VERIFY").
+		BoolVar(&cfg.alertManagerInsecureSkipVerify)
+
+	kingpin.Flag("alertmanager.proxy-url", "HTTP proxy to reach AlertManager through").
+		Envar("ALERT_MANAGER_PROXY_URL").
+		StringVar(&cfg.alertManagerProxyURL)
+
+	kingpin.Flag("alertmanager.header", "Extra header of AlertManager calls as 'Name: value', e.g. 'X-Scope-OrgID: team-a', repeatable").
+		Envar("ALERT_MANAGER_HEADERS").
+		StringsVar(&cfg.alertManagerHeaders)
+
+	kingpin.Flag("instance.name", "Instance name, instances with different names manage only their own silences").
+		Envar("INSTANCE_NAME").
+		Default("").
+		StringVar(&cfg.instanceName)
+
+	kingpin.Flag("reconcile.interval", "How often silences are reconciled with Alertmanager, 0 disables reconciliation").
+		Envar("RECONCILE_INTERVAL").
+		Default("1m").
+		DurationVar(&cfg.reconcileInterval)
+
+	kingpin.Flag("silence.lookahead", "How far ahead silences of upcoming maintenance windows are created, 0 creates silences when windows start").
+		Envar("SILENCE_LOOKAHEAD").
+		Default("0s").
+		DurationVar(&cfg.silenceLookahead)
+
+	kingpin.Flag("exporter.enabled", "Publish maintenances as silencer_maintenance_active series labelled by equality matchers").
+		Envar("EXPORTER_ENABLED").
+		BoolVar(&cfg.exporterEnabled)
+
+	kingpin.Flag("exporter.upcoming", "How soon window has to start to be published as silencer_maintenance_upcoming series").
+		Envar("EXPORTER_UPCOMING").
+		Default("1h").
+		DurationVar(&cfg.exporterUpcoming)
+
+	kingpin.Flag("storage.path", "Directory state of silencer, e.g. maintenances created via API, is kept in").
+		Envar("STORAGE_PATH").
+		Default("data/silencer").
+		StringVar(&cfg.storagePath)
+
+	kingpin.Flag("storage.type", "How state is stored: file per key or single BoltDB file").
+		Envar("STORAGE_TYPE").
+		Default(silencer.StateStoreFile).
+		EnumVar(&cfg.storageType, silencer.StateStoreFile, silencer.StateStoreBolt)
+
+	kingpin.Flag("history.retention", "How long history entries are kept, 0 keeps them forever").
+		Envar("HISTORY_RETENTION").
+		Default("2208h").
+		DurationVar(&cfg.historyRetention)
 
 	kingpin.Parse()
 	return &cfg
@@ -28,3 +28,34 @@ func BindGracefulStop(ctx context.Context, stoppers ...stopper) <-chan error {
 
 	return errChan
 }
+
+type reloader interface {
+	Reload() error
+}
+
+// BindReload calls reloaders on every SIGHUP until ctx is done.
+// Reload errors are not fatal, so they are reported through separate channel.
+func BindReload(ctx context.Context, reloaders ...reloader) <-chan error {
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGHUP)
+	errChan := make(chan error)
+	go func() {
+		defer close(errChan)
+		defer signal.Stop(signalChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signalChan:
+				for _, r := range reloaders {
+					err := r.Reload()
+					if err != nil {
+						errChan <- err
+					}
+				}
+			}
+		}
+	}()
+
+	return errChan
+}
@@ -3,22 +3,22 @@ package silencer
 import "sync"
 
 type ActiveMaintenanceStorage struct {
-	items map[MaintenanceHash]struct{}
+	items map[MaintenanceHash]ActiveSilenceID
 	mux   sync.RWMutex
 }
 
 func NewActiveMaintenanceStorage() *ActiveMaintenanceStorage {
 	return &ActiveMaintenanceStorage{
-		make(map[MaintenanceHash]struct{}),
+		make(map[MaintenanceHash]ActiveSilenceID),
 		sync.RWMutex{},
 	}
 }
 
-func (s *ActiveMaintenanceStorage) Add(hash MaintenanceHash) {
+func (s *ActiveMaintenanceStorage) Add(hash MaintenanceHash, silenceID ActiveSilenceID) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
 
-	s.items[hash] = struct{}{}
+	s.items[hash] = silenceID
 }
 
 func (s *ActiveMaintenanceStorage) Delete(hash MaintenanceHash) {
@@ -28,10 +28,15 @@ func (s *ActiveMaintenanceStorage) Delete(hash MaintenanceHash) {
 	delete(s.items, hash)
 }
 
-func (s *ActiveMaintenanceStorage) IsActive(hash MaintenanceHash) bool {
+func (s *ActiveMaintenanceStorage) Get(hash MaintenanceHash) (ActiveSilenceID, bool) {
 	s.mux.RLock()
 	defer s.mux.RUnlock()
 
-	_, ok := s.items[hash]
+	silenceID, ok := s.items[hash]
+	return silenceID, ok
+}
+
+func (s *ActiveMaintenanceStorage) IsActive(hash MaintenanceHash) bool {
+	_, ok := s.Get(hash)
 	return ok
 }
@@ -0,0 +1,177 @@
+package silencer
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+
+	clientruntime "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/api/v2/client"
+	commoncfg "github.com/prometheus/common/config"
+)
+
+const alertmanagerAPIPath = "/api/v2"
+
+// AlertmanagerConfig describes how Alertmanager is reached: instances and their mode, auth, TLS, proxy and extra headers.
+type AlertmanagerConfig struct {
+	URLs       []*url.URL
+	Mode       string
+	HTTPConfig commoncfg.HTTPClientConfig
+	Headers    map[string]string
+	// ExternalURL is Alertmanager UI silences are linked to, url of instance is used when it is empty
+	ExternalURL string
+}
+
+// WithYaml overrides settings with ones from config file section. Urls, mode and http_config replace the current ones,
+// headers are merged. Relative file paths of http_config are resolved against dir.
+func (c AlertmanagerConfig) WithYaml(y *YamlAlertmanager, dir string) (AlertmanagerConfig, error) {
+	if y == nil {
+		return c, nil
+	}
+
+	if y.URL != "" && len(y.URLs) > 0 {
+		return AlertmanagerConfig{}, errors.New("alertmanager url and urls are mutually exclusive")
+	}
+
+	rawURLs := y.URLs
+	if y.URL != "" {
+		rawURLs = []string{y.URL}
+	}
+	if len(rawURLs) > 0 {
+		urls, err := ParseAlertmanagerURLs(rawURLs)
+		if err != nil {
+			return AlertmanagerConfig{}, err
+		}
+		c.URLs = urls
+	}
+
+	if y.Mode != "" {
+		c.Mode = y.Mode
+	}
+
+	if y.ExternalURL != "" {
+		c.ExternalURL = y.ExternalURL
+	}
+
+	if y.HTTPConfig != nil {
+		httpConfig := *y.HTTPConfig
+		httpConfig.SetDirectory(dir)
+		c.HTTPConfig = httpConfig
+	}
+
+	headers := make(map[string]string, len(c.Headers)+len(y.Headers))
+	for name, value := range c.Headers {
+		headers[name] = value
+	}
+	for name, value := range y.Headers {
+		headers[name] = value
+	}
+	c.Headers = headers
+
+	return c, nil
+}
+
+// AlertmanagerConfigs returns settings of default Alertmanager, base overridden by alertmanager section,
+// along with named ones from alertmanagers section.
+func AlertmanagerConfigs(base AlertmanagerConfig, config YamlConfig, dir string) (map[string]AlertmanagerConfig, error) {
+	result := make(map[string]AlertmanagerConfig, len(config.Alertmanagers)+1)
+
+	defaultConfig, err := base.WithYaml(config.Alertmanager, dir)
+	if err != nil {
+		return nil, err
+	}
+	result[DefaultTargetName] = defaultConfig
+
+	for name, y := range config.Alertmanagers {
+		if name == DefaultTargetName {
+			return nil, errors.Errorf("alertmanager name %s is reserved for alertmanager section", DefaultTargetName)
+		}
+
+		if y == nil || (y.URL == "" && len(y.URLs) == 0) {
+			return nil, errors.Errorf("no urls of alertmanager %s", name)
+		}
+
+		cfg, err := AlertmanagerConfig{HTTPConfig: commoncfg.DefaultHTTPClientConfig}.WithYaml(y, dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid alertmanager %s", name)
+		}
+		result[name] = cfg
+	}
+
+	return result, nil
+}
+
+func ParseAlertmanagerURLs(rawURLs []string) ([]*url.URL, error) {
+	urls := make([]*url.URL, len(rawURLs))
+	for i, rawURL := range rawURLs {
+		u, err := url.ParseRequestURI(rawURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid alertmanager url")
+		}
+		urls[i] = u
+	}
+
+	return urls, nil
+}
+
+// NewAlertmanagerClient creates API client of Alertmanager instance at u. Credentials of url are used as basic auth,
+// unless auth is configured explicitly.
+func NewAlertmanagerClient(u *url.URL, cfg AlertmanagerConfig) (*client.Alertmanager, error) {
+	err := cfg.HTTPConfig.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid alertmanager http config")
+	}
+
+	httpClient, err := commoncfg.NewClientFromConfig(cfg.HTTPConfig, "alertmanager", false, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create alertmanager http client")
+	}
+	httpClient.Transport = newHeadersRoundTripper(cfg.Headers, httpClient.Transport)
+
+	schemes := []string{"http"}
+	if u.Scheme != "" {
+		schemes = []string{u.Scheme}
+	}
+
+	cr := clientruntime.NewWithClient(u.Host, path.Join(u.Path, alertmanagerAPIPath), schemes, httpClient)
+
+	if u.User != nil && cfg.HTTPConfig.BasicAuth == nil && cfg.HTTPConfig.Authorization == nil {
+		password, _ := u.User.Password()
+		cr.DefaultAuthentication = clientruntime.BasicAuth(u.User.Username(), password)
+	}
+
+	return client.New(cr, strfmt.Default), nil
+}
+
+// headersRoundTripper sets extra headers, e.g. X-Scope-OrgID of multi-tenant Alertmanager.
+type headersRoundTripper struct {
+	headers map[string]string
+	rt      http.RoundTripper
+}
+
+func newHeadersRoundTripper(headers map[string]string, rt http.RoundTripper) http.RoundTripper {
+	if len(headers) == 0 {
+		return rt
+	}
+
+	return &headersRoundTripper{headers, rt}
+}
+
+// redactedURL is url without credentials
+func redactedURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+
+	return redacted.String()
+}
+
+func (rt *headersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range rt.headers {
+		req.Header.Set(name, value)
+	}
+
+	return rt.rt.RoundTrip(req)
+}
@@ -0,0 +1,95 @@
+package silencer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAlertmanagerClient(t *testing.T) {
+	testCases := []struct {
+		name            string
+		user            *url.Userinfo
+		httpConfig      commoncfg.HTTPClientConfig
+		yaml            *YamlAlertmanager
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "basic auth from url",
+			user: url.UserPassword("user", "secret"),
+			expectedHeaders: map[string]string{
+				"Authorization": "Basic dXNlcjpzZWNyZXQ=",
+			},
+		},
+		{
+			name: "bearer token and headers",
+			httpConfig: commoncfg.HTTPClientConfig{
+				BearerToken: "token",
+			},
+			yaml: &YamlAlertmanager{
+				Headers: map[string]string{"X-Scope-OrgID": "team-a"},
+			},
+			expectedHeaders: map[string]string{
+				"Authorization": "Bearer token",
+				"X-Scope-OrgID": "team-a",
+			},
+		},
+		{
+			name: "config section replaces flags",
+			httpConfig: commoncfg.HTTPClientConfig{
+				BearerToken: "token",
+			},
+			yaml: &YamlAlertmanager{
+				HTTPConfig: &commoncfg.HTTPClientConfig{
+					BasicAuth: &commoncfg.BasicAuth{Username: "user", Password: "secret"},
+				},
+			},
+			expectedHeaders: map[string]string{
+				"Authorization": "Basic dXNlcjpzZWNyZXQ=",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var headers http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header
+				assert.Equal(t, "/prefix/api/v2/silences", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte("[]"))
+			}))
+			defer server.Close()
+
+			u, err := url.Parse(server.URL + "/prefix")
+			if err != nil {
+				t.Fatal(err)
+			}
+			u.User = tc.user
+
+			cfg, err := AlertmanagerConfig{URLs: []*url.URL{u}, HTTPConfig: tc.httpConfig}.WithYaml(tc.yaml, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cli, err := NewAlertmanagerClient(u, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = NewSilenceService(cli.Silence).ActiveSilences(context.Background(), "maintenance service")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, value := range tc.expectedHeaders {
+				assert.Equal(t, value, headers.Get(name), name)
+			}
+		})
+	}
+}
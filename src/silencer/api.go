@@ -0,0 +1,271 @@
+package silencer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const defaultOccurrencesRange = 7 * 24 * time.Hour
+
+// maxOccurrencesRange limits range of occurrences, so they could not be listed of too many windows
+const maxOccurrencesRange = 92 * 24 * time.Hour
+
+type APIMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+}
+
+type APIWindow struct {
+	StartAt time.Time `json:"startAt"`
+	EndAt   time.Time `json:"endAt"`
+}
+
+// APIOverride is manual change of maintenance window along with who made it
+type APIOverride struct {
+	Action string    `json:"action"`
+	Actor  string    `json:"actor"`
+	At     time.Time `json:"at"`
+	Window APIWindow `json:"window"`
+}
+
+type APITarget struct {
+	Name      string `json:"name"`
+	IsActive  bool   `json:"isActive"`
+	SilenceID string `json:"silenceId,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+type APIMaintenance struct {
+	// Identity is stable maintenance identity, ID is optional user-assigned one
+	Identity     string       `json:"identity"`
+	ID           string       `json:"id,omitempty"`
+	Matchers     []APIMatcher `json:"matchers"`
+	Schedule     string       `json:"schedule,omitempty"`
+	Recurrence   string       `json:"recurrence,omitempty"`
+	Start        string       `json:"start,omitempty"`
+	End          string       `json:"end,omitempty"`
+	Duration     string       `json:"duration"`
+	Timezone     string       `json:"timezone"`
+	Alertmanager string       `json:"alertmanager"`
+	Source       string       `json:"source"`
+	Author       string       `json:"author,omitempty"`
+	Comment      string       `json:"comment,omitempty"`
+	Tags         []string     `json:"tags,omitempty"`
+	IsActive     bool         `json:"isActive"`
+	IsFinished   bool         `json:"isFinished"`
+	// SilenceID is silence of the current window, the one of the first target when there are several
+	SilenceID string `json:"silenceId,omitempty"`
+	// Window is the current window, it is set only for active maintenance
+	Window *APIWindow `json:"window,omitempty"`
+	// Override is set, when window is started, ended or extended manually
+	Override  *APIOverride `json:"override,omitempty"`
+	Next      *time.Time   `json:"next,omitempty"`
+	LastError string       `json:"lastError,omitempty"`
+	Targets   []APITarget  `json:"targets"`
+}
+
+// APIMaintenanceSpec is maintenance created or updated via API: either schedule or recurrence with duration,
+// or start and end of one-off window.
+type APIMaintenanceSpec struct {
+	ID           string   `json:"id,omitempty"`
+	Matchers     []string `json:"matchers"`
+	Schedule     string   `json:"schedule,omitempty"`
+	Recurrence   string   `json:"recurrence,omitempty"`
+	Duration     string   `json:"duration,omitempty"`
+	Start        string   `json:"start,omitempty"`
+	End          string   `json:"end,omitempty"`
+	Timezone     string   `json:"timezone,omitempty"`
+	Alertmanager string   `json:"alertmanager,omitempty"`
+	Author       string   `json:"author"`
+	Comment      string   `json:"comment,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+func (s APIMaintenanceSpec) YamlMaintenance() YamlMaintenance {
+	return YamlMaintenance{
+		ID:           s.ID,
+		Matchers:     s.Matchers,
+		Schedule:     s.Schedule,
+		Recurrence:   s.Recurrence,
+		Duration:     s.Duration,
+		Start:        s.Start,
+		End:          s.End,
+		Timezone:     s.Timezone,
+		Alertmanager: s.Alertmanager,
+		Author:       s.Author,
+		Comment:      s.Comment,
+		Tags:         s.Tags,
+	}
+}
+
+// APIWindowActionSpec is request to start, end or extend maintenance window. Window is extended either
+// to endAt or by duration.
+type APIWindowActionSpec struct {
+	Author   string     `json:"author"`
+	EndAt    *time.Time `json:"endAt,omitempty"`
+	Duration string     `json:"duration,omitempty"`
+}
+
+type APIOccurrence struct {
+	StartAt  time.Time `json:"startAt"`
+	EndAt    time.Time `json:"endAt"`
+	IsActive bool      `json:"isActive"`
+	// Exception is set for skipped occurrence, which is listed at its scheduled time, and for postponed one,
+	// which is listed at its new time
+	Exception *APIException `json:"exception,omitempty"`
+}
+
+type APIException struct {
+	Action      string     `json:"action"`
+	ScheduledAt time.Time  `json:"scheduledAt"`
+	StartAt     *time.Time `json:"startAt,omitempty"`
+	Actor       string     `json:"actor"`
+	At          time.Time  `json:"at"`
+}
+
+// APIExceptionSpec is request to skip occurrence scheduled at scheduledAt or to postpone it to startAt.
+type APIExceptionSpec struct {
+	ScheduledAt time.Time  `json:"scheduledAt"`
+	Action      string     `json:"action"`
+	StartAt     *time.Time `json:"startAt,omitempty"`
+	Author      string     `json:"author"`
+}
+
+type APIError struct {
+	Error string `json:"error"`
+}
+
+func NewAPIMaintenance(m WatchedMaintenance, yamlMaintenance YamlMaintenance) APIMaintenance {
+	result := APIMaintenance{
+		Identity:     m.Maintenance.Hash.String(),
+		ID:           m.Maintenance.ID,
+		Matchers:     make([]APIMatcher, 0, len(m.Maintenance.Matchers)),
+		Schedule:     yamlMaintenance.Schedule,
+		Recurrence:   yamlMaintenance.Recurrence,
+		Start:        yamlMaintenance.Start,
+		End:          yamlMaintenance.End,
+		Duration:     m.Maintenance.Duration.String(),
+		Timezone:     m.Maintenance.Location.String(),
+		Alertmanager: m.Maintenance.Alertmanager,
+		Source:       m.Maintenance.Source,
+		Author:       yamlMaintenance.Author,
+		Comment:      yamlMaintenance.Comment,
+		Tags:         yamlMaintenance.Tags,
+		IsActive:     m.IsActive,
+		IsFinished:   m.IsFinished,
+		LastError:    errorString(m.LastError),
+		Targets:      make([]APITarget, len(m.Targets)),
+	}
+
+	for _, matcher := range m.Maintenance.Matchers {
+		if matcher == nil || matcher.Name == nil || matcher.Value == nil || matcher.IsRegex == nil {
+			continue
+		}
+
+		result.Matchers = append(result.Matchers, APIMatcher{*matcher.Name, *matcher.Value, *matcher.IsRegex})
+	}
+
+	for i, t := range m.Targets {
+		result.Targets[i] = APITarget{
+			Name:      t.Name,
+			IsActive:  t.IsActive,
+			SilenceID: string(t.SilenceID),
+			LastError: errorString(t.LastError),
+		}
+
+		if result.SilenceID == "" {
+			result.SilenceID = string(t.SilenceID)
+		}
+	}
+
+	if !m.Window.StartAt.IsZero() {
+		result.Window = &APIWindow{m.Window.StartAt, m.Window.EndAt}
+	}
+
+	if m.Override != nil {
+		result.Override = &APIOverride{
+			Action: m.Override.Action,
+			Actor:  m.Override.Actor,
+			At:     m.Override.At,
+			Window: APIWindow{m.Override.Window.StartAt, m.Override.Window.EndAt},
+		}
+	}
+
+	if !m.Next.IsZero() {
+		next := m.Next
+		result.Next = &next
+	}
+
+	return result
+}
+
+func NewAPIException(e OccurrenceException) APIException {
+	result := APIException{
+		Action:      e.Action,
+		ScheduledAt: e.ScheduledAt,
+		Actor:       e.Actor,
+		At:          e.At,
+	}
+
+	if !e.StartAt.IsZero() {
+		startAt := e.StartAt
+		result.StartAt = &startAt
+	}
+
+	return result
+}
+
+// Occurrences returns windows of maintenance overlapping [from, to] along with skipped ones.
+func Occurrences(m Maintenance, exceptions []OccurrenceException, from, to, now time.Time) []APIOccurrence {
+	postponed := make(map[int64]OccurrenceException)
+	result := make([]APIOccurrence, 0)
+	for _, e := range exceptions {
+		if e.Action == ExceptionActionPostpone {
+			postponed[e.StartAt.Unix()] = e
+			continue
+		}
+
+		w := Window{e.ScheduledAt, e.ScheduledAt.Add(m.Duration)}
+		if w.EndAt.After(from) && !w.StartAt.After(to) {
+			exception := NewAPIException(e)
+			result = append(result, APIOccurrence{StartAt: w.StartAt, EndAt: w.EndAt, Exception: &exception})
+		}
+	}
+
+	for _, w := range m.Windows(from.Add(-m.Duration), to) {
+		if !w.EndAt.After(from) {
+			continue
+		}
+
+		occurrence := APIOccurrence{
+			StartAt:  w.StartAt,
+			EndAt:    w.EndAt,
+			IsActive: w.Contains(now),
+		}
+		if e, ok := postponed[w.StartAt.Unix()]; ok {
+			exception := NewAPIException(e)
+			occurrence.Exception = &exception
+		}
+
+		result = append(result, occurrence)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartAt.Before(result[j].StartAt)
+	})
+
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, APIError{err.Error()})
+}
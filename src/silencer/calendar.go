@@ -0,0 +1,135 @@
+package silencer
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// calendarProductID identifies silencer as producer of iCalendar feed
+const calendarProductID = "-//nwlunatic//prometheus-alertmanager-silencer//EN"
+
+// calendarLineLimit is maximal length of content line in octets, longer lines are folded
+const calendarLineLimit = 75
+
+const calendarTimeLayout = "20060102T150405Z"
+
+// CalendarEvent is single occurrence of maintenance in iCalendar feed
+type CalendarEvent struct {
+	Maintenance APIMaintenance
+	StartAt     time.Time
+	EndAt       time.Time
+}
+
+// UID is stable across feed refreshes: identity of maintenance with start of occurrence.
+func (e CalendarEvent) UID() string {
+	return e.Maintenance.Identity + "-" + e.StartAt.UTC().Format(calendarTimeLayout) + "@silencer"
+}
+
+// Summary names maintenance by id, identity is used when there is none.
+func (e CalendarEvent) Summary() string {
+	name := e.Maintenance.Identity
+	if e.Maintenance.ID != "" {
+		name = e.Maintenance.ID
+	}
+
+	return "Maintenance " + name
+}
+
+// Description lists matchers one per line, followed by comment and author.
+func (e CalendarEvent) Description() string {
+	lines := make([]string, 0, len(e.Maintenance.Matchers)+2)
+	for _, m := range e.Maintenance.Matchers {
+		operator := "="
+		if m.IsRegex {
+			operator = "=~"
+		}
+		lines = append(lines, m.Name+operator+m.Value)
+	}
+
+	if e.Maintenance.Comment != "" {
+		lines = append(lines, "", e.Maintenance.Comment)
+	}
+
+	if e.Maintenance.Author != "" {
+		lines = append(lines, "Author: "+e.Maintenance.Author)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// RenderCalendar writes events as RFC 5545 calendar, dtstamp is time feed is generated at.
+func RenderCalendar(events []CalendarEvent, dtstamp time.Time) []byte {
+	buf := bytes.Buffer{}
+	writeLine := func(name string, value string) {
+		writeCalendarLine(&buf, name+":"+value)
+	}
+
+	writeLine("BEGIN", "VCALENDAR")
+	writeLine("VERSION", "2.0")
+	writeLine("PRODID", calendarProductID)
+	writeLine("CALSCALE", "GREGORIAN")
+	writeLine("METHOD", "PUBLISH")
+	writeLine("X-WR-CALNAME", "Maintenances")
+
+	for _, e := range events {
+		writeLine("BEGIN", "VEVENT")
+		writeLine("UID", escapeCalendarText(e.UID()))
+		writeLine("DTSTAMP", dtstamp.UTC().Format(calendarTimeLayout))
+		writeLine("DTSTART", e.StartAt.UTC().Format(calendarTimeLayout))
+		writeLine("DTEND", e.EndAt.UTC().Format(calendarTimeLayout))
+		writeLine("SUMMARY", escapeCalendarText(e.Summary()))
+		writeLine("DESCRIPTION", escapeCalendarText(e.Description()))
+
+		if len(e.Maintenance.Tags) > 0 {
+			categories := make([]string, len(e.Maintenance.Tags))
+			for i, tag := range e.Maintenance.Tags {
+				categories[i] = escapeCalendarText(tag)
+			}
+			writeLine("CATEGORIES", strings.Join(categories, ","))
+		}
+
+		writeLine("STATUS", "CONFIRMED")
+		writeLine("TRANSP", "TRANSPARENT")
+		writeLine("END", "VEVENT")
+	}
+
+	writeLine("END", "VCALENDAR")
+
+	return buf.Bytes()
+}
+
+var calendarTextEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// escapeCalendarText escapes TEXT value as required by RFC 5545 section 3.3.11.
+func escapeCalendarText(value string) string {
+	return calendarTextEscaper.Replace(value)
+}
+
+// writeCalendarLine writes content line terminated by CRLF, folding it into lines of at most 75 octets.
+// Continuation lines start with space, multi-octet characters are never split.
+func writeCalendarLine(buf *bytes.Buffer, line string) {
+	limit := calendarLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// leading space of continuation line counts towards its length
+		limit = calendarLineLimit - 1
+	}
+
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
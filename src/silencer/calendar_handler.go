@@ -0,0 +1,139 @@
+package silencer
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+// defaultCalendarRange is how far into the future occurrences are published by default
+const defaultCalendarRange = 30 * 24 * time.Hour
+
+// maxCalendarRange limits range of feed, so it could not be built of too many occurrences
+const maxCalendarRange = 366 * 24 * time.Hour
+
+// CalendarHandler serves upcoming occurrences of maintenances as iCalendar feed.
+type CalendarHandler struct {
+	watchedMaintenanceStorage watchedMaintenanceStorage
+	yamlMaintenanceIndex      yamlMaintenanceIndex
+	clock                     clock
+}
+
+func NewCalendarHandler(
+	watchedMaintenanceStorage watchedMaintenanceStorage,
+	yamlMaintenanceIndex yamlMaintenanceIndex,
+	clock clock,
+) *CalendarHandler {
+	return &CalendarHandler{
+		watchedMaintenanceStorage,
+		yamlMaintenanceIndex,
+		clock,
+	}
+}
+
+// Handle publishes occurrences, which are not over yet, till to (RFC3339), the next 30 days by default.
+// Skipped occurrences are left out. Repeatable matcher (= or =~) and tag parameters keep only maintenances having
+// all of them.
+func (h *CalendarHandler) Handle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := h.clock.Now()
+		to, err := parseTimeParam(r, "to", now.Add(defaultCalendarRange))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if to.Sub(now) > maxCalendarRange {
+			http.Error(w, errors.Errorf("range is longer than %s", maxCalendarRange).Error(), http.StatusBadRequest)
+			return
+		}
+
+		matchers := make([]*labels.Matcher, 0)
+		for _, value := range r.URL.Query()["matcher"] {
+			matcher, err := labels.ParseMatcher(value)
+			if err != nil {
+				http.Error(w, errors.Wrapf(err, "invalid matcher %q", value).Error(), http.StatusBadRequest)
+				return
+			}
+			// silences have no negative matchers, so neither do maintenances
+			if matcher.Type != labels.MatchEqual && matcher.Type != labels.MatchRegexp {
+				http.Error(w, errors.Errorf("matcher %q is not supported, use = or =~", value).Error(), http.StatusBadRequest)
+				return
+			}
+			matchers = append(matchers, matcher)
+		}
+		tags := r.URL.Query()["tag"]
+
+		events := make([]CalendarEvent, 0)
+		for _, m := range h.watchedMaintenanceStorage.WatchedMaintenances() {
+			yamlMaintenance := h.yamlMaintenanceIndex.Get(m.Maintenance.Hash)
+			if !hasMatchers(m.Maintenance.Matchers, matchers) || !hasTags(yamlMaintenance.Tags, tags) {
+				continue
+			}
+
+			apiMaintenance := NewAPIMaintenance(m, yamlMaintenance)
+			for _, o := range Occurrences(m.Maintenance, m.Exceptions, now, to, now) {
+				if o.Exception != nil && o.Exception.Action == ExceptionActionSkip {
+					continue
+				}
+
+				events = append(events, CalendarEvent{apiMaintenance, o.StartAt, o.EndAt})
+			}
+		}
+
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].StartAt.Before(events[j].StartAt)
+		})
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+		_, _ = w.Write(RenderCalendar(events, now))
+	}
+}
+
+// hasMatchers tells whether every one of wanted matchers is among matchers of maintenance.
+// Wanted matchers are either equality or regex ones.
+func hasMatchers(matchers models.Matchers, wanted []*labels.Matcher) bool {
+	for _, w := range wanted {
+		found := false
+		for _, m := range matchers {
+			if m == nil || m.Name == nil || m.Value == nil || m.IsRegex == nil {
+				continue
+			}
+
+			if *m.Name == w.Name && *m.Value == w.Value && *m.IsRegex == (w.Type == labels.MatchRegexp) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasTags tells whether every one of wanted tags is among tags.
+func hasTags(tags []string, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, tag := range tags {
+			if tag == w {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
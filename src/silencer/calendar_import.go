@@ -0,0 +1,346 @@
+package silencer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli"
+	"github.com/teambition/rrule-go"
+)
+
+// ImportedCalendar is maintenances made of events of iCalendar file.
+type ImportedCalendar struct {
+	YamlMaintenances []YamlMaintenance
+	Maintenances     []Maintenance
+	// Skipped are events, which could not become maintenances, e.g. lacking mapped property
+	Skipped []error
+}
+
+// ParseCalendar turns every event of iCalendar content into maintenance identified by UID of event.
+// RRULE, RDATE and EXDATE of event are expanded into occurrences, modified occurrence, which has RECURRENCE-ID,
+// replaces the one of its recurring event and becomes maintenance of its own. Cancelled events are left out.
+// Error is returned only for invalid content or calendar settings, invalid events are reported as skipped.
+func ParseCalendar(calendar YamlCalendar, content []byte) (ImportedCalendar, error) {
+	location := time.Local
+	if calendar.Timezone != "" {
+		var err error
+		location, err = time.LoadLocation(calendar.Timezone)
+		if err != nil {
+			return ImportedCalendar{}, err
+		}
+	}
+
+	matchers, err := parseMatchers(calendar.Matchers)
+	if err != nil {
+		return ImportedCalendar{}, err
+	}
+
+	typeMatchers, err := cli.TypeMatchers(matchers)
+	if err != nil {
+		return ImportedCalendar{}, err
+	}
+
+	components, err := parseICal(content)
+	if err != nil {
+		return ImportedCalendar{}, errors.Wrapf(err, "invalid calendar %s", calendar.File)
+	}
+
+	events := make([]icalComponent, 0)
+	for _, c := range components {
+		for _, e := range c.Components {
+			if e.Name == "VEVENT" {
+				events = append(events, e)
+			}
+		}
+	}
+
+	result := ImportedCalendar{
+		YamlMaintenances: make([]YamlMaintenance, 0),
+		Maintenances:     make([]Maintenance, 0),
+		Skipped:          make([]error, 0),
+	}
+
+	// modified occurrences exclude their original ones from recurring events
+	excluded := make(map[string][]icalProperty)
+	for _, e := range events {
+		if recurrenceID, ok := e.Property("RECURRENCE-ID"); ok {
+			uid := e.Text("UID")
+			excluded[uid] = append(excluded[uid], recurrenceID)
+		}
+	}
+
+	importer := calendarImporter{calendar, location, typeMatchers}
+	seen := make(map[MaintenanceHash]bool, len(events))
+	for _, e := range events {
+		yamlMaintenance, maintenance, ok, err := importer.event(e, excluded)
+		if err != nil {
+			result.Skipped = append(result.Skipped, errors.Wrapf(err, "event %s of %s", e.Text("UID"), calendar.File))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if seen[maintenance.Hash] {
+			result.Skipped = append(result.Skipped, errors.Errorf("duplicate event %s of %s", yamlMaintenance.ID, calendar.File))
+			continue
+		}
+		seen[maintenance.Hash] = true
+
+		result.YamlMaintenances = append(result.YamlMaintenances, yamlMaintenance)
+		result.Maintenances = append(result.Maintenances, maintenance)
+	}
+
+	return result, nil
+}
+
+type calendarImporter struct {
+	calendar YamlCalendar
+	location *time.Location
+	matchers models.Matchers
+}
+
+// event makes maintenance of event, ok is false for cancelled event.
+func (i calendarImporter) event(
+	e icalComponent,
+	excluded map[string][]icalProperty,
+) (yamlMaintenance YamlMaintenance, maintenance Maintenance, ok bool, err error) {
+	if strings.EqualFold(e.Text("STATUS"), "CANCELLED") {
+		return YamlMaintenance{}, Maintenance{}, false, nil
+	}
+
+	uid := e.Text("UID")
+	if uid == "" {
+		return YamlMaintenance{}, Maintenance{}, false, errors.New("UID is missing")
+	}
+
+	yamlMaintenance = YamlMaintenance{
+		ID:           uid,
+		Alertmanager: i.calendar.Alertmanager,
+		Comment:      e.Text("SUMMARY"),
+	}
+
+	if recurrenceID, ok := e.Property("RECURRENCE-ID"); ok {
+		times, _, err := parseICalTimes(recurrenceID, i.location)
+		if err != nil {
+			return YamlMaintenance{}, Maintenance{}, false, err
+		}
+		yamlMaintenance.ID += "/" + times[0].UTC().Format(icalUTCTimeLayout)
+	}
+
+	matchers, err := i.eventMatchers(e)
+	if err != nil {
+		return YamlMaintenance{}, Maintenance{}, false, err
+	}
+	for _, m := range matchers {
+		operator := "="
+		if *m.IsRegex {
+			operator = "=~"
+		}
+		yamlMaintenance.Matchers = append(yamlMaintenance.Matchers, *m.Name+operator+*m.Value)
+	}
+
+	start, isDate, duration, err := i.eventWindow(e)
+	if err != nil {
+		return YamlMaintenance{}, Maintenance{}, false, err
+	}
+	yamlMaintenance.Timezone = start.Location().String()
+
+	var schedule rrule.Set
+	schedule.DTStart(start)
+	rrules := e.All("RRULE")
+	rdates := e.All("RDATE")
+	switch {
+	case len(rrules) > 1:
+		return YamlMaintenance{}, Maintenance{}, false, errors.New("several RRULE are not supported")
+	case len(rrules) == 0 && len(rdates) == 0:
+		yamlMaintenance.Start = start.Format(time.RFC3339)
+		yamlMaintenance.End = start.Add(duration).Format(time.RFC3339)
+	default:
+		yamlMaintenance.Recurrence = strings.Join(recurrenceLines(e, start), " ")
+		yamlMaintenance.Duration = duration.String()
+	}
+
+	if len(rrules) == 1 {
+		// rule is parsed as recurrence of config, so frequency is limited the same way
+		options, err := parseRecurrenceOptions(recurrenceRule(rrules[0], start), start.Location())
+		if err != nil {
+			return YamlMaintenance{}, Maintenance{}, false, errors.Wrap(err, "invalid RRULE")
+		}
+
+		r, err := rrule.NewRRule(*options)
+		if err != nil {
+			return YamlMaintenance{}, Maintenance{}, false, errors.Wrap(err, "invalid RRULE")
+		}
+		schedule.RRule(r)
+	}
+
+	added, err := i.eventTimes(rdates, start, isDate)
+	if err != nil {
+		return YamlMaintenance{}, Maintenance{}, false, err
+	}
+
+	removed, err := i.eventTimes(append(e.All("EXDATE"), excluded[uid]...), start, isDate)
+	if err != nil {
+		return YamlMaintenance{}, Maintenance{}, false, err
+	}
+
+	// DTSTART is the first occurrence, RRULE yields it, otherwise it is one of dates
+	if len(rrules) == 0 && !containsTime(added, start) && !containsTime(removed, start) {
+		added = append(added, start)
+	}
+
+	// dates are sorted in advance, so schedule does not have to sort them on every iteration
+	sort.Slice(added, func(i, j int) bool { return added[i].Before(added[j]) })
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Before(removed[j]) })
+	schedule.SetRDates(added)
+	schedule.SetExDates(removed)
+
+	maintenance = Maintenance{
+		Hash:         yamlMaintenance.Identity(),
+		Matchers:     matchers,
+		Schedule:     NewRecurrenceSetSchedule(&schedule),
+		Duration:     duration,
+		Location:     start.Location(),
+		ID:           yamlMaintenance.ID,
+		ContentHash:  yamlMaintenance.Hash(),
+		Alertmanager: i.calendar.Alertmanager,
+	}
+	if yamlMaintenance.Recurrence == "" {
+		maintenance.Schedule = OneOffSchedule{start}
+	}
+	if maintenance.Alertmanager == "" {
+		maintenance.Alertmanager = DefaultTargetName
+	}
+
+	return yamlMaintenance, maintenance, true, nil
+}
+
+// eventMatchers are matchers of calendar followed by ones of mapped properties, sorted by label name.
+// Property listing several values, e.g. CATEGORIES:db,web, becomes regex matcher of any of them.
+func (i calendarImporter) eventMatchers(e icalComponent) (models.Matchers, error) {
+	properties := make([]string, 0, len(i.calendar.Properties))
+	for property := range i.calendar.Properties {
+		properties = append(properties, property)
+	}
+	sort.Slice(properties, func(a, b int) bool {
+		return i.calendar.Properties[properties[a]] < i.calendar.Properties[properties[b]]
+	})
+
+	result := append(models.Matchers{}, i.matchers...)
+	for _, property := range properties {
+		p, ok := e.Property(strings.ToUpper(property))
+		if !ok || p.Value == "" {
+			return nil, errors.Errorf("property %s is missing", property)
+		}
+
+		name, value, isRegex := i.calendar.Properties[property], unescapeICalText(p.Value), false
+		if values := splitICalText(p.Value); len(values) > 1 {
+			for j, v := range values {
+				values[j] = regexp.QuoteMeta(strings.TrimSpace(v))
+			}
+			value, isRegex = strings.Join(values, "|"), true
+		}
+
+		result = append(result, &models.Matcher{Name: &name, Value: &value, IsRegex: &isRegex})
+	}
+
+	// silence without matchers is rejected by Alertmanager
+	if len(result) == 0 {
+		return nil, errors.New("no matchers")
+	}
+
+	return result, nil
+}
+
+// eventWindow returns start and duration of event. Event of whole days without DTEND and DURATION lasts a day.
+func (i calendarImporter) eventWindow(e icalComponent) (time.Time, bool, time.Duration, error) {
+	dtstart, ok := e.Property("DTSTART")
+	if !ok {
+		return time.Time{}, false, 0, errors.New("DTSTART is missing")
+	}
+
+	starts, isDate, err := parseICalTimes(dtstart, i.location)
+	if err != nil {
+		return time.Time{}, false, 0, err
+	}
+	start := starts[0]
+
+	var duration time.Duration
+	if dtend, ok := e.Property("DTEND"); ok {
+		ends, _, err := parseICalTimes(dtend, start.Location())
+		if err != nil {
+			return time.Time{}, false, 0, err
+		}
+		duration = ends[0].Sub(start)
+	} else if p, ok := e.Property("DURATION"); ok {
+		duration, err = parseICalDuration(p.Value)
+		if err != nil {
+			return time.Time{}, false, 0, err
+		}
+	} else if isDate {
+		duration = 24 * time.Hour
+	}
+
+	if duration <= 0 {
+		return time.Time{}, false, 0, errors.Errorf("duration %s is not positive", duration)
+	}
+
+	return start, isDate, duration, nil
+}
+
+// eventTimes parses RDATE or EXDATE properties. Date is turned into time of start of event on that date,
+// when event starts at time of day.
+func (i calendarImporter) eventTimes(properties []icalProperty, start time.Time, isDate bool) ([]time.Time, error) {
+	result := make([]time.Time, 0)
+	for _, p := range properties {
+		times, isPropertyDate, err := parseICalTimes(p, start.Location())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range times {
+			if isPropertyDate && !isDate {
+				t = time.Date(t.Year(), t.Month(), t.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+			}
+			result = append(result, t)
+		}
+	}
+
+	return result, nil
+}
+
+// recurrenceLines describes recurrence of event, they are shown as recurrence of maintenance.
+// RRULE is given along with DTSTART, the way recurrence of config is.
+func recurrenceLines(e icalComponent, start time.Time) []string {
+	result := make([]string, 0)
+	for _, p := range e.All("RRULE") {
+		result = append(result, recurrenceRule(p, start))
+	}
+	for _, name := range []string{"RDATE", "EXDATE"} {
+		for _, p := range e.All(name) {
+			result = append(result, name+":"+p.Value)
+		}
+	}
+
+	return result
+}
+
+// recurrenceRule is RRULE prefixed by DTSTART of event as wall clock of its location.
+func recurrenceRule(rule icalProperty, start time.Time) string {
+	return "DTSTART=" + start.Format(icalLocalTimeLayout) + ";" + rule.Value
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, item := range times {
+		if item.Equal(t) {
+			return true
+		}
+	}
+
+	return false
+}
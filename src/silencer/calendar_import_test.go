@@ -0,0 +1,268 @@
+package silencer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//test//EN
+BEGIN:VEVENT
+UID:patch-tuesday
+SUMMARY:Patch Tuesday
+DTSTART;TZID=Europe/Berlin:20210413T220000
+DTEND;TZID=Europe/Berlin:20210413T233000
+RRULE:FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2
+EXDATE;TZID=Europe/Berlin:20210511T220000
+RDATE;TZID=Europe/Berlin:20210520T220000
+LOCATION:db-1
+CATEGORIES:db,web
+END:VEVENT
+BEGIN:VEVENT
+UID:patch-tuesday
+RECURRENCE-ID;TZID=Europe/Berlin:20210608T220000
+SUMMARY:Patch Tuesday
+DTSTART;TZID=Europe/Berlin:20210609T010000
+DURATION:PT2H
+LOCATION:db-1
+CATEGORIES:db
+END:VEVENT
+BEGIN:VEVENT
+UID:month-end
+SUMMARY:Month end
+  closing
+DTSTART:20210430T200000Z
+DURATION:PT1H
+RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=3
+LOCATION:db-2
+CATEGORIES:db
+END:VEVENT
+BEGIN:VEVENT
+UID:holiday
+DTSTART;VALUE=DATE:20210501
+LOCATION:db-3
+CATEGORIES:db
+END:VEVENT
+BEGIN:VEVENT
+UID:failover-drill
+DTSTART:20210505T220000Z
+DURATION:PT1H
+RDATE:20210510T220000Z,20210520T220000Z
+EXDATE:20210520T220000Z
+LOCATION:db-5
+CATEGORIES:db
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+STATUS:CANCELLED
+DTSTART:20210501T000000Z
+DURATION:PT1H
+LOCATION:db-4
+CATEGORIES:db
+END:VEVENT
+BEGIN:VEVENT
+UID:no-location
+DTSTART:20210501T000000Z
+DURATION:PT1H
+CATEGORIES:db
+END:VEVENT
+BEGIN:VEVENT
+UID:hourly
+DTSTART:20210501T000000Z
+DURATION:PT5M
+RRULE:FREQ=HOURLY
+LOCATION:db-6
+CATEGORIES:db
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParseCalendar(t *testing.T) {
+	calendar := YamlCalendar{
+		File:       "maintenances.ics",
+		Matchers:   []string{"env=prod"},
+		Properties: map[string]string{"location": "instance", "categories": "job"},
+		Timezone:   "UTC",
+	}
+
+	imported, err := ParseCalendar(calendar, []byte(strings.ReplaceAll(testCalendar, "\n", "\r\n")))
+	require.NoError(t, err)
+	require.Len(t, imported.Maintenances, 5)
+	require.Len(t, imported.Skipped, 2)
+	assert.Contains(t, imported.Skipped[0].Error(), "event no-location of maintenances.ics: property location is missing")
+	assert.Contains(t, imported.Skipped[1].Error(), "event hourly of maintenances.ics: invalid RRULE")
+
+	byID := make(map[string]Maintenance)
+	for _, m := range imported.Maintenances {
+		byID[m.ID] = m
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	from := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		id       string
+		matchers []string
+		duration time.Duration
+		expected []time.Time
+	}{
+		{
+			id:       "patch-tuesday",
+			matchers: []string{"env=prod", "instance=db-1", "job=~db|web"},
+			duration: 90 * time.Minute,
+			expected: []time.Time{
+				time.Date(2021, 4, 13, 22, 0, 0, 0, berlin),
+				// May 11 is excluded, May 20 is added
+				time.Date(2021, 5, 20, 22, 0, 0, 0, berlin),
+				// June 8 is moved to its own event
+				time.Date(2021, 7, 13, 22, 0, 0, 0, berlin),
+			},
+		},
+		{
+			id:       "patch-tuesday/20210608T200000Z",
+			matchers: []string{"env=prod", "instance=db-1", "job=db"},
+			duration: 2 * time.Hour,
+			expected: []time.Time{time.Date(2021, 6, 9, 1, 0, 0, 0, berlin)},
+		},
+		{
+			id:       "month-end",
+			matchers: []string{"env=prod", "instance=db-2", "job=db"},
+			duration: time.Hour,
+			expected: []time.Time{
+				time.Date(2021, 4, 30, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 5, 31, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 6, 30, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			id:       "failover-drill",
+			matchers: []string{"env=prod", "instance=db-5", "job=db"},
+			duration: time.Hour,
+			expected: []time.Time{
+				// DTSTART is the first occurrence of event without RRULE too
+				time.Date(2021, 5, 5, 22, 0, 0, 0, time.UTC),
+				time.Date(2021, 5, 10, 22, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			id:       "holiday",
+			matchers: []string{"env=prod", "instance=db-3", "job=db"},
+			duration: 24 * time.Hour,
+			expected: []time.Time{time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			m, ok := byID[tc.id]
+			require.True(t, ok)
+			assert.Equal(t, DefaultTargetName, m.Alertmanager)
+			assert.Equal(t, tc.duration, m.Duration)
+
+			matchers := make([]string, 0, len(m.Matchers))
+			for _, matcher := range m.Matchers {
+				operator := "="
+				if *matcher.IsRegex {
+					operator = "=~"
+				}
+				matchers = append(matchers, *matcher.Name+operator+*matcher.Value)
+			}
+			assert.Equal(t, tc.matchers, matchers)
+
+			actual := make([]time.Time, 0)
+			for next := m.Schedule.Next(from); !next.IsZero() && len(actual) < 4; next = m.Schedule.Next(next) {
+				if next.Year() > 2021 || next.Month() > time.July {
+					break
+				}
+				actual = append(actual, next)
+			}
+			require.Len(t, actual, len(tc.expected))
+			for i := range tc.expected {
+				assert.True(t, tc.expected[i].Equal(actual[i]), "expected %s, got %s", tc.expected[i], actual[i])
+			}
+		})
+	}
+
+	monthEnd := byID["month-end"]
+	isActive, _ := monthEnd.ActiveAt(time.Date(2021, 7, 30, 20, 30, 0, 0, time.UTC))
+	assert.False(t, isActive, "recurrence is over after COUNT occurrences")
+	assert.True(t, monthEnd.FinishedAt(time.Date(2021, 6, 30, 21, 0, 0, 0, time.UTC)))
+	assert.False(t, monthEnd.FinishedAt(time.Date(2021, 6, 30, 20, 30, 0, 0, time.UTC)))
+
+	yamlMaintenance := imported.YamlMaintenances[0]
+	assert.Equal(t, "patch-tuesday", yamlMaintenance.ID)
+	assert.Equal(t, "Patch Tuesday", yamlMaintenance.Comment)
+	assert.Equal(t, "Europe/Berlin", yamlMaintenance.Timezone)
+	assert.Empty(t, yamlMaintenance.Schedule)
+	assert.Equal(
+		t,
+		"DTSTART=20210413T220000;FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2 RDATE:20210520T220000 EXDATE:20210511T220000",
+		yamlMaintenance.Recurrence,
+	)
+	assert.Equal(t, "Month end closing", imported.YamlMaintenances[2].Comment, "folded line is unfolded")
+}
+
+func TestParseCalendarErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		calendar YamlCalendar
+		content  string
+	}{
+		{
+			name:     "unknown timezone",
+			calendar: YamlCalendar{Timezone: "Mars/Olympus"},
+			content:  "BEGIN:VCALENDAR\nEND:VCALENDAR\n",
+		},
+		{
+			name:     "invalid matcher",
+			calendar: YamlCalendar{Matchers: []string{"job"}},
+			content:  "BEGIN:VCALENDAR\nEND:VCALENDAR\n",
+		},
+		{
+			name:    "component is not ended",
+			content: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCalendar(tc.calendar, []byte(tc.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseICalDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		isValid  bool
+	}{
+		{"PT1H30M", 90 * time.Minute, true},
+		{"P1W", 7 * 24 * time.Hour, true},
+		{"P1DT12H", 36 * time.Hour, true},
+		{"-PT15M", -15 * time.Minute, true},
+		{"P1M", 0, false},
+		{"PT", 0, false},
+		{"1H", 0, false},
+		{"PT1", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := parseICalDuration(tc.value)
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
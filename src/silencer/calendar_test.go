@@ -0,0 +1,101 @@
+package silencer
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarHandler(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 30, 0, 0, time.UTC)
+
+	backup := YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"job=db", "instance=~db-.*"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+		Comment:  "nightly backup; replicas lag",
+		Tags:     []string{"db", "nightly"},
+	}
+	deploy := YamlMaintenance{
+		ID:       "deploy",
+		Matchers: []string{"job=web"},
+		Start:    "2021-04-09T12:00:00Z",
+		End:      "2021-04-09T13:00:00Z",
+	}
+	b := MustMaintenance(ParseMaintenance(backup))
+	d := MustMaintenance(ParseMaintenance(deploy))
+	skipped := []OccurrenceException{
+		{Action: ExceptionActionSkip, ScheduledAt: time.Date(2021, 4, 8, 3, 0, 0, 0, time.UTC)},
+	}
+
+	h := NewCalendarHandler(
+		watchedMaintenanceStorageMock{items: []WatchedMaintenance{
+			{Maintenance: withExceptions(b, skipped), Exceptions: skipped},
+			{Maintenance: d},
+		}},
+		BuildYamlMaintenanceIndex([]YamlMaintenance{backup, deploy}),
+		ClockMock{now},
+	).Handle()
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := serve("/calendar.ics?to=2021-04-10T00:00:00Z")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(body, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Equal(t, 3, strings.Count(body, "BEGIN:VEVENT"), "active and upcoming ones, skipped one is left out")
+	assert.Contains(t, body, "UID:"+b.Hash.String()+"-20210407T030000Z@silencer\r\n")
+	assert.NotContains(t, body, "DTSTART:20210408T030000Z")
+	assert.Contains(t, body, "DTSTART:20210409T120000Z\r\nDTEND:20210409T130000Z\r\n")
+	assert.Contains(t, body, "SUMMARY:Maintenance backup\r\n")
+	assert.Contains(t, body, `DESCRIPTION:job=db\ninstance=~db-.*\n\nnightly backup\; replicas lag`+"\r\n")
+	assert.Contains(t, body, "CATEGORIES:db,nightly\r\n")
+	for _, line := range strings.Split(body, "\r\n") {
+		assert.LessOrEqual(t, len(line), calendarLineLimit)
+	}
+
+	w = serve("/calendar.ics?to=2021-04-10T00:00:00Z&matcher=job%3Dweb")
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "BEGIN:VEVENT"), "filtered by matcher")
+	assert.Contains(t, w.Body.String(), "SUMMARY:Maintenance deploy\r\n")
+
+	w = serve("/calendar.ics?to=2021-04-10T00:00:00Z&matcher=instance%3D~db-.*&tag=nightly")
+	assert.Equal(t, 2, strings.Count(w.Body.String(), "BEGIN:VEVENT"), "filtered by regex matcher and tag")
+
+	w = serve("/calendar.ics?tag=nightly&tag=weekly")
+	assert.Equal(t, 0, strings.Count(w.Body.String(), "BEGIN:VEVENT"), "every tag is required")
+
+	w = serve("/calendar.ics?matcher=job")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve("/calendar.ics?matcher=job%21%3Dweb")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "negative matcher is not supported")
+
+	w = serve("/calendar.ics?matcher=job%21~web")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "negative matcher is not supported")
+}
+
+func TestWriteCalendarLine(t *testing.T) {
+	buf := bytes.Buffer{}
+	writeCalendarLine(&buf, "DESCRIPTION:"+strings.Repeat("я", 70))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, 74, len(lines[0]), "two-octet characters are not split")
+	assert.True(t, strings.HasPrefix(lines[1], " я"))
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("я", 70)+"\r\n", unfolded)
+}
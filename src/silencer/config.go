@@ -4,6 +4,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/alertmanager/cli"
 	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/common/model"
@@ -24,11 +25,27 @@ func Parse(reader io.Reader) (Config, error) {
 }
 
 func ConfigFromYaml(config YamlConfig) (Config, error) {
+	if _, ok := config.Alertmanagers[DefaultTargetName]; ok {
+		return Config{}, errors.Errorf("alertmanager name %s is reserved for alertmanager section", DefaultTargetName)
+	}
+
 	maintenances, err := ParseMaintenances(config.Maintenances)
 	if err != nil {
 		return Config{}, err
 	}
 
+	for _, m := range maintenances {
+		if _, ok := config.Alertmanagers[m.Alertmanager]; !ok && m.Alertmanager != DefaultTargetName {
+			return Config{}, errors.Errorf("maintenance %s refers to unknown alertmanager %s", m.Hash, m.Alertmanager)
+		}
+	}
+
+	for _, c := range config.Calendars {
+		if _, ok := config.Alertmanagers[c.Alertmanager]; !ok && c.Alertmanager != "" {
+			return Config{}, errors.Errorf("calendar %s refers to unknown alertmanager %s", c.File, c.Alertmanager)
+		}
+	}
+
 	c := Config{
 		maintenances,
 	}
@@ -38,12 +55,21 @@ func ConfigFromYaml(config YamlConfig) (Config, error) {
 
 func ParseMaintenances(maintenances []YamlMaintenance) ([]Maintenance, error) {
 	result := make([]Maintenance, len(maintenances))
+	hashes := make(map[MaintenanceHash]struct{}, len(maintenances))
 	for i, m := range maintenances {
 		var err error
 		result[i], err = ParseMaintenance(m)
 		if err != nil {
 			return nil, err
 		}
+
+		if _, ok := hashes[result[i].Hash]; ok {
+			if m.ID != "" {
+				return nil, errors.Errorf("duplicate maintenance id %s", m.ID)
+			}
+			return nil, errors.Errorf("duplicate maintenance %s", result[i].Hash)
+		}
+		hashes[result[i].Hash] = struct{}{}
 	}
 
 	return result, nil
@@ -60,25 +86,83 @@ func ParseMaintenance(maintenance YamlMaintenance) (Maintenance, error) {
 		return Maintenance{}, err
 	}
 
-	schedule, err := cron.ParseStandard(maintenance.Schedule)
-	if err != nil {
-		return Maintenance{}, err
+	location := time.Local
+	if maintenance.Timezone != "" {
+		location, err = time.LoadLocation(maintenance.Timezone)
+		if err != nil {
+			return Maintenance{}, err
+		}
 	}
 
-	d, err := model.ParseDuration(maintenance.Duration)
+	schedule, duration, err := parseWindow(maintenance, location)
 	if err != nil {
 		return Maintenance{}, err
 	}
-	duration := time.Duration(d)
+
+	alertmanager := maintenance.Alertmanager
+	if alertmanager == "" {
+		alertmanager = DefaultTargetName
+	}
 
 	return Maintenance{
-		maintenance.Hash(),
+		maintenance.Identity(),
 		typeMatchers,
 		schedule,
 		duration,
+		location,
+		maintenance.ID,
+		maintenance.Hash(),
+		alertmanager,
+		MaintenanceSourceFile,
 	}, nil
 }
 
+// parseWindow parses either cron schedule or recurrence with duration, or one-off window with start and end.
+func parseWindow(maintenance YamlMaintenance, location *time.Location) (cron.Schedule, time.Duration, error) {
+	if maintenance.Start == "" && maintenance.End == "" {
+		var schedule cron.Schedule
+		var err error
+		if maintenance.Recurrence != "" {
+			if maintenance.Schedule != "" {
+				return nil, 0, errors.New("recurrence can not be combined with schedule")
+			}
+			schedule, err = parseRecurrence(maintenance.Recurrence, location)
+		} else {
+			schedule, err = parseSchedule(maintenance.Schedule, location)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		d, err := model.ParseDuration(maintenance.Duration)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return schedule, time.Duration(d), nil
+	}
+
+	if maintenance.Schedule != "" || maintenance.Recurrence != "" || maintenance.Duration != "" {
+		return nil, 0, errors.New("start and end can not be combined with schedule, recurrence and duration")
+	}
+
+	start, err := time.Parse(time.RFC3339, maintenance.Start)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "invalid start")
+	}
+
+	end, err := time.Parse(time.RFC3339, maintenance.End)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "invalid end")
+	}
+
+	if !end.After(start) {
+		return nil, 0, errors.Errorf("end %s is not after start %s", maintenance.End, maintenance.Start)
+	}
+
+	return OneOffSchedule{start.In(location)}, end.Sub(start), nil
+}
+
 func parseMatchers(inputMatchers []string) ([]labels.Matcher, error) {
 	matchers := make([]labels.Matcher, 0, len(inputMatchers))
 
@@ -0,0 +1,212 @@
+package silencer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type maintenanceReloader interface {
+	Reload(maintenances []Maintenance)
+}
+
+// ConfigReloader applies config file. Maintenances of config file and events of calendar files it refers to
+// are set to their own sources together.
+type ConfigReloader struct {
+	configFile         string
+	maintenanceSources maintenanceSourcesSetter
+	metrics            *Metrics
+	logger             logrus.FieldLogger
+
+	checksum       [sha256.Size]byte
+	failedChecksum [sha256.Size]byte
+	mux            sync.Mutex
+}
+
+func NewConfigReloader(
+	configFile string,
+	maintenanceSources maintenanceSourcesSetter,
+	metrics *Metrics,
+	logger logrus.FieldLogger,
+) *ConfigReloader {
+	return &ConfigReloader{
+		configFile:         configFile,
+		maintenanceSources: maintenanceSources,
+		metrics:            metrics,
+		logger:             logger,
+	}
+}
+
+// Reload reads config file and applies it. Invalid config is not applied,
+// previously loaded maintenances keep working.
+func (r *ConfigReloader) Reload() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	content, err := r.read()
+	if err != nil {
+		r.metrics.ObserveConfigReload(r.checksum, err)
+		return err
+	}
+
+	return r.reload(content)
+}
+
+// ReloadIfChanged applies config file only when its content, or content of calendar files, differs
+// from the last seen one.
+func (r *ConfigReloader) ReloadIfChanged() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	content, err := r.read()
+	if err != nil {
+		r.metrics.ObserveConfigReload(r.checksum, err)
+		return err
+	}
+
+	if content.checksum == r.checksum || content.checksum == r.failedChecksum {
+		return nil
+	}
+
+	return r.reload(content)
+}
+
+// configContent is content of config file along with content of its calendar files
+type configContent struct {
+	config []byte
+	// calendars are in order of calendars section
+	calendars [][]byte
+	checksum  [sha256.Size]byte
+}
+
+// read reads config file and calendar files it refers to. Calendars of config, which could not be parsed,
+// are not read, parse error is reported on apply.
+func (r *ConfigReloader) read() (configContent, error) {
+	config, err := ioutil.ReadFile(r.configFile)
+	if err != nil {
+		return configContent{}, err
+	}
+
+	content := configContent{config: config}
+	checksum := sha256.New()
+	_, _ = checksum.Write(config)
+
+	yamlConfig, err := ParseYaml(bytes.NewReader(config))
+	if err == nil {
+		for _, c := range yamlConfig.Calendars {
+			calendar, err := ioutil.ReadFile(r.calendarFile(c.File))
+			if err != nil {
+				return configContent{}, errors.Wrap(err, "failed to read calendar")
+			}
+
+			content.calendars = append(content.calendars, calendar)
+			_, _ = checksum.Write(calendar)
+		}
+	}
+	copy(content.checksum[:], checksum.Sum(nil))
+
+	return content, nil
+}
+
+// calendarFile resolves path of calendar file relative to config file.
+func (r *ConfigReloader) calendarFile(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+
+	return filepath.Join(filepath.Dir(r.configFile), file)
+}
+
+// reload applies config and records outcome in metrics.
+func (r *ConfigReloader) reload(content configContent) error {
+	err := r.apply(content)
+	r.metrics.ObserveConfigReload(r.checksum, err)
+
+	return err
+}
+
+func (r *ConfigReloader) apply(content configContent) error {
+	yamlConfig, err := ParseYaml(bytes.NewReader(content.config))
+	if err != nil {
+		r.failedChecksum = content.checksum
+		return errors.Wrap(err, "failed to parse config")
+	}
+
+	config, err := ConfigFromYaml(yamlConfig)
+	if err != nil {
+		r.failedChecksum = content.checksum
+		return errors.Wrap(err, "invalid config")
+	}
+
+	calendars := ImportedCalendar{}
+	for i, c := range yamlConfig.Calendars {
+		calendar, err := ParseCalendar(c, content.calendars[i])
+		if err != nil {
+			r.failedChecksum = content.checksum
+			return errors.Wrapf(err, "invalid calendar %s", c.File)
+		}
+
+		calendars.YamlMaintenances = append(calendars.YamlMaintenances, calendar.YamlMaintenances...)
+		calendars.Maintenances = append(calendars.Maintenances, calendar.Maintenances...)
+		calendars.Skipped = append(calendars.Skipped, calendar.Skipped...)
+	}
+
+	err = r.maintenanceSources.Set(map[string]SourceMaintenances{
+		MaintenanceSourceFile:     {yamlConfig.Maintenances, config.Maintenances},
+		MaintenanceSourceCalendar: {calendars.YamlMaintenances, calendars.Maintenances},
+	})
+	if err != nil {
+		r.failedChecksum = content.checksum
+		return errors.Wrap(err, "invalid config")
+	}
+	r.checksum = content.checksum
+
+	for _, err := range calendars.Skipped {
+		r.logger.WithError(err).Warn("calendar event is skipped")
+	}
+
+	r.logger.Infof(
+		"config %s loaded, %d maintenances, %d calendar events",
+		r.configFile,
+		len(config.Maintenances),
+		len(calendars.Maintenances),
+	)
+
+	return nil
+}
+
+type ConfigWatcher struct {
+	*periodicRunner
+	configReloader *ConfigReloader
+	logger         logrus.FieldLogger
+}
+
+func NewConfigWatcher(
+	configReloader *ConfigReloader,
+	interval time.Duration,
+	logger logrus.FieldLogger,
+) *ConfigWatcher {
+	return &ConfigWatcher{
+		newPeriodicRunner(interval),
+		configReloader,
+		logger,
+	}
+}
+
+// Start polls config file for changes. Polling is used instead of fs events,
+// because mounted ConfigMaps are updated by swapping symlinks.
+// Zero interval disables watching.
+func (w *ConfigWatcher) Start() {
+	w.start(func() {
+		err := w.configReloader.ReloadIfChanged()
+		if err != nil {
+			w.logger.WithError(err).Error("failed to reload config")
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package silencer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	exporterActiveMetric   = metricsNamespace + "_maintenance_active"
+	exporterUpcomingMetric = metricsNamespace + "_maintenance_upcoming"
+)
+
+// exporterLabels identify maintenance in exported series, matchers of the same names are not exported
+var exporterLabels = []string{"maintenance", "maintenance_id"}
+
+// ExporterCollector publishes maintenances as series, which alerting rules could be joined with, e.g.
+// `up == 0 unless on(instance) silencer_maintenance_active`. Equality matchers of maintenance become labels.
+// silencer_maintenance_active is present while window is open, silencer_maintenance_upcoming is present
+// while window starts within upcoming, its value is start of the window.
+type ExporterCollector struct {
+	watchedMaintenanceStorage watchedMaintenanceStorage
+	upcoming                  time.Duration
+	clock                     clock
+}
+
+func NewExporterCollector(
+	watchedMaintenanceStorage watchedMaintenanceStorage,
+	upcoming time.Duration,
+	clock clock,
+) *ExporterCollector {
+	return &ExporterCollector{
+		watchedMaintenanceStorage: watchedMaintenanceStorage,
+		upcoming:                  upcoming,
+		clock:                     clock,
+	}
+}
+
+// Describe reports nothing, label names differ between maintenances, so collector is unchecked.
+func (c *ExporterCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *ExporterCollector) Collect(ch chan<- prometheus.Metric) {
+	now := c.clock.Now()
+	for _, m := range c.watchedMaintenanceStorage.WatchedMaintenances() {
+		names, values := exporterLabelValues(m.Maintenance)
+
+		if !m.Window.StartAt.IsZero() {
+			desc := prometheus.NewDesc(exporterActiveMetric, "Maintenance window is open.", names, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, values...)
+		}
+
+		if !m.Next.IsZero() && m.Next.Sub(now) <= c.upcoming {
+			desc := prometheus.NewDesc(exporterUpcomingMetric, "Start of maintenance window starting soon.", names, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(m.Next.Unix()), values...)
+		}
+	}
+}
+
+// exporterLabelValues turns equality matchers of maintenance into labels. Regex matchers, reserved and
+// invalid label names are skipped.
+func exporterLabelValues(m Maintenance) ([]string, []string) {
+	names := append(make([]string, 0, len(exporterLabels)+len(m.Matchers)), exporterLabels...)
+	values := append(make([]string, 0, cap(names)), m.Hash.String(), m.ID)
+
+	seen := make(map[string]bool, cap(names))
+	for _, name := range exporterLabels {
+		seen[name] = true
+	}
+
+	for _, matcher := range m.Matchers {
+		if matcher == nil || matcher.Name == nil || matcher.Value == nil || matcher.IsRegex == nil || *matcher.IsRegex {
+			continue
+		}
+
+		name := *matcher.Name
+		if seen[name] || strings.HasPrefix(name, model.ReservedLabelPrefix) || !model.LabelName(name).IsValid() {
+			continue
+		}
+		seen[name] = true
+
+		names = append(names, name)
+		values = append(values, *matcher.Value)
+	}
+
+	return names, values
+}
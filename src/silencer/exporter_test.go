@@ -0,0 +1,131 @@
+package silencer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExporterCollector(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+	backup := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"instance=db1", "job=~postgres.*", "maintenance=ignored"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	vacuum := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "vacuum",
+		Matchers: []string{"instance=db2"},
+		Schedule: "0 4 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	report := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "report",
+		Matchers: []string{"instance=db3"},
+		Schedule: "0 12 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewExporterCollector(watchedMaintenanceStorageMock{
+		items: []WatchedMaintenance{
+			{Maintenance: backup, Next: backup.Schedule.Next(now), Window: Window{now.Add(-10 * time.Minute), now.Add(50 * time.Minute)}},
+			{Maintenance: vacuum, Next: vacuum.Schedule.Next(now)},
+			{Maintenance: report, Next: report.Schedule.Next(now)},
+		},
+	}, time.Hour, ClockMock{now}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series := make(map[string]map[string]string)
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			series[f.GetName()+"/"+labels["maintenance_id"]] = labels
+		}
+	}
+
+	assert.Equal(t, map[string]map[string]string{
+		"silencer_maintenance_active/backup": {
+			"maintenance":    backup.Hash.String(),
+			"maintenance_id": "backup",
+			"instance":       "db1",
+		},
+		"silencer_maintenance_upcoming/vacuum": {
+			"maintenance":    vacuum.Hash.String(),
+			"maintenance_id": "vacuum",
+			"instance":       "db2",
+		},
+	}, series)
+}
+
+func TestExporterCollector_SeveralSeries(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+	maintenance := func(id string, alertmanager string, matchers ...string) Maintenance {
+		return MustMaintenance(ParseMaintenance(YamlMaintenance{
+			ID:           id,
+			Matchers:     matchers,
+			Schedule:     "0 3 * * *",
+			Duration:     "1h",
+			Timezone:     "UTC",
+			Alertmanager: alertmanager,
+		}))
+	}
+	backup := maintenance("backup", "", "instance=db1")
+	deploy := maintenance("deploy", "tenant-a", "job=web", "env=prod")
+	vacuum := maintenance("vacuum", "tenant-a", "instance=db2", "job=postgres")
+	window := Window{now.Add(-10 * time.Minute), now.Add(50 * time.Minute)}
+	upcoming := now.Add(20 * time.Minute)
+	targets := []WatchedTarget{{Name: DefaultTargetName, IsActive: true}, {Name: "tenant-a", IsActive: true}}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewExporterCollector(watchedMaintenanceStorageMock{
+		items: []WatchedMaintenance{
+			{Maintenance: backup, Next: upcoming, Window: window, Targets: targets},
+			{Maintenance: deploy, Next: upcoming, Window: window, Targets: targets},
+			{Maintenance: vacuum, Next: upcoming, Targets: targets},
+		},
+	}, time.Hour, ClockMock{now}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	for _, f := range families {
+		_, err := expfmt.MetricFamilyToText(&buf, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// series of the same family have different label sets, one series per maintenance regardless of targets
+	series := func(metric string, labels string, m Maintenance, value string) string {
+		return metric + "{" + labels + `maintenance="` + m.Hash.String() + `",maintenance_id="` + m.ID + `"} ` + value + "\n"
+	}
+	expected := "# HELP silencer_maintenance_active Maintenance window is open.\n" +
+		"# TYPE silencer_maintenance_active gauge\n" +
+		series("silencer_maintenance_active", `instance="db1",`, backup, "1") +
+		series("silencer_maintenance_active", `env="prod",job="web",`, deploy, "1") +
+		"# HELP silencer_maintenance_upcoming Start of maintenance window starting soon.\n" +
+		"# TYPE silencer_maintenance_upcoming gauge\n" +
+		series("silencer_maintenance_upcoming", `instance="db1",`, backup, "1.6177662e+09") +
+		series("silencer_maintenance_upcoming", `instance="db2",job="postgres",`, vacuum, "1.6177662e+09") +
+		series("silencer_maintenance_upcoming", `env="prod",job="web",`, deploy, "1.6177662e+09")
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,81 @@
+package silencer
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type readinessChecker interface {
+	Ready() error
+}
+
+// HealthHandler serves liveness and readiness probes.
+type HealthHandler struct {
+	readinessChecker           readinessChecker
+	alertmanagersHealthStorage alertmanagersHealthStorage
+	maxAge                     time.Duration
+	clock                      clock
+}
+
+// NewHealthHandler creates handler, which considers Alertmanager reachable, when its last call succeeded
+// within maxAge. Zero maxAge checks only outcome of the last call.
+func NewHealthHandler(
+	readinessChecker readinessChecker,
+	alertmanagersHealthStorage alertmanagersHealthStorage,
+	maxAge time.Duration,
+	clock clock,
+) *HealthHandler {
+	return &HealthHandler{
+		readinessChecker,
+		alertmanagersHealthStorage,
+		maxAge,
+		clock,
+	}
+}
+
+// Healthy tells process is alive.
+func (h *HealthHandler) Healthy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}
+}
+
+// Ready passes once silences are recovered, scheduler is running and every target has reachable Alertmanager.
+func (h *HealthHandler) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.readinessChecker.Ready()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		unreachable := h.unreachableTargets()
+		if len(unreachable) > 0 {
+			http.Error(w, "alertmanager is unreachable: "+strings.Join(unreachable, ", "), http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte("OK"))
+	}
+}
+
+// unreachableTargets lists targets none of Alertmanager instances of which is reachable.
+func (h *HealthHandler) unreachableTargets() []string {
+	now := h.clock.Now()
+	reachable := make(map[string]bool)
+	for _, health := range h.alertmanagersHealthStorage.AlertmanagersHealth() {
+		reachable[health.Target] = reachable[health.Target] || health.IsReachable(now, h.maxAge)
+	}
+
+	result := make([]string, 0)
+	for target, ok := range reachable {
+		if !ok {
+			result = append(result, target)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
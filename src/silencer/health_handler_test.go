@@ -0,0 +1,75 @@
+package silencer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler_Ready(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+	reachable := AlertmanagerHealth{Target: "default", LastSuccess: now.Add(-time.Minute)}
+	failed := AlertmanagerHealth{Target: "default", LastSuccess: now.Add(-time.Minute), LastErrorAt: now}
+	stale := AlertmanagerHealth{Target: "default", LastSuccess: now.Add(-time.Hour)}
+
+	testCases := []struct {
+		name           string
+		readyErr       error
+		alertmanagers  []AlertmanagerHealth
+		expectedStatus int
+	}{
+		{
+			name:           "ready",
+			alertmanagers:  []AlertmanagerHealth{reachable},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not recovered",
+			readyErr:       errors.New("silences are not recovered yet"),
+			alertmanagers:  []AlertmanagerHealth{reachable},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "the last call failed",
+			alertmanagers:  []AlertmanagerHealth{failed},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "no recent calls",
+			alertmanagers:  []AlertmanagerHealth{stale},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "one of peers is reachable",
+			alertmanagers:  []AlertmanagerHealth{failed, reachable},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHealthHandler(
+				readinessCheckerMock{tc.readyErr},
+				alertmanagersHealthStorageMock(tc.alertmanagers),
+				10*time.Minute,
+				ClockMock{now},
+			)
+
+			w := httptest.NewRecorder()
+			h.Ready()(w, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+type readinessCheckerMock struct {
+	err error
+}
+
+func (m readinessCheckerMock) Ready() error {
+	return m.err
+}
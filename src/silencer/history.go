@@ -0,0 +1,343 @@
+package silencer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Events of history entries
+const (
+	HistoryEventSilenceCreated = "silence_created"
+	HistoryEventSilenceUpdated = "silence_updated"
+	HistoryEventSilenceDeleted = "silence_deleted"
+	HistoryEventSilenceFailed  = "silence_failed"
+	// HistoryEventManual is action made via API, e.g. window started early or occurrence skipped
+	HistoryEventManual = "manual"
+)
+
+// Actions causing silence events, manual actions are named after override and exception actions,
+// along with create, update, delete and restore.
+const (
+	HistoryActionSchedule  = "schedule"
+	HistoryActionExpire    = "expire"
+	HistoryActionReconcile = "reconcile"
+	HistoryActionReload    = "reload"
+	HistoryActionCreate    = "create"
+	HistoryActionUpdate    = "update"
+	HistoryActionDelete    = "delete"
+	HistoryActionRestore   = "restore"
+)
+
+// historyOperations describe failed silence call in error of silence_failed entry
+var historyOperations = map[string]string{
+	HistoryEventSilenceCreated: "create silence",
+	HistoryEventSilenceUpdated: "update silence",
+	HistoryEventSilenceDeleted: "delete silence",
+}
+
+// HistoryEntry is single lifecycle event of maintenance. Silence events carry the action, which caused them,
+// and the actor, when it is manual one.
+type HistoryEntry struct {
+	At          time.Time       `json:"at"`
+	Event       string          `json:"event"`
+	Action      string          `json:"action,omitempty"`
+	Maintenance MaintenanceHash `json:"maintenance"`
+	Target      string          `json:"target,omitempty"`
+	SilenceID   ActiveSilenceID `json:"silenceId,omitempty"`
+	Window      *APIWindow      `json:"window,omitempty"`
+	Actor       string          `json:"actor,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// HistoryFilter selects history entries, zero fields match any entry.
+type HistoryFilter struct {
+	// Maintenances match entry of any of them
+	Maintenances []MaintenanceHash
+	Event        string
+	Action       string
+	Actor        string
+	Target       string
+	SilenceID    ActiveSilenceID
+	From         time.Time
+	To           time.Time
+}
+
+func (f HistoryFilter) Matches(e HistoryEntry) bool {
+	return f.matchesMaintenance(e.Maintenance) &&
+		(f.Event == "" || f.Event == e.Event) &&
+		(f.Action == "" || f.Action == e.Action) &&
+		(f.Actor == "" || f.Actor == e.Actor) &&
+		(f.Target == "" || f.Target == e.Target) &&
+		(f.SilenceID == "" || f.SilenceID == e.SilenceID) &&
+		(f.From.IsZero() || !e.At.Before(f.From)) &&
+		(f.To.IsZero() || e.At.Before(f.To))
+}
+
+func (f HistoryFilter) matchesMaintenance(hash MaintenanceHash) bool {
+	for _, m := range f.Maintenances {
+		if m == hash {
+			return true
+		}
+	}
+
+	return len(f.Maintenances) == 0
+}
+
+type historyRecorder interface {
+	Record(entry HistoryEntry)
+}
+
+// historyCompactInterval is how often entries older than retention are dropped from history file
+const historyCompactInterval = time.Hour
+
+// History is append-only log of maintenance lifecycle events, kept as JSON lines in file.
+// Entries older than retention are dropped, zero retention keeps them forever.
+type History struct {
+	*periodicRunner
+	file      string
+	retention time.Duration
+	clock     clock
+	logger    logrus.FieldLogger
+
+	// lastAt is time of the latest entry, entries are recorded in time order even when clock goes back
+	lastAt time.Time
+	mux    sync.Mutex
+}
+
+func NewHistory(file string, retention time.Duration, clock clock, logger logrus.FieldLogger) *History {
+	interval := historyCompactInterval
+	if retention <= 0 {
+		interval = 0
+	}
+
+	return &History{
+		periodicRunner: newPeriodicRunner(interval),
+		file:           file,
+		retention:      retention,
+		clock:          clock,
+		logger:         logger,
+	}
+}
+
+// Start drops entries older than retention right away and then every historyCompactInterval,
+// apart from silence calls, which record entries.
+func (h *History) Start() {
+	compact := func() {
+		err := h.compact()
+		if err != nil {
+			h.logger.WithError(err).Error("failed to drop old history entries")
+		}
+	}
+
+	compact()
+	h.start(compact)
+}
+
+// Record appends entry at the current time. Failure is logged only, so it never breaks silencing.
+func (h *History) Record(entry HistoryEntry) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	entry.At = h.clock.Now()
+	if entry.At.Before(h.lastAt) {
+		entry.At = h.lastAt
+	}
+	h.lastAt = entry.At
+
+	err := h.append(entry)
+	if err != nil {
+		h.logger.WithError(err).Errorf("failed to record %s of maintenance %s", entry.Event, entry.Maintenance)
+	}
+}
+
+// compact rewrites history file without entries older than retention, order of entries is kept.
+// Lines, which could not be decoded, are kept. File is rewritten only when some entries are dropped.
+func (h *History) compact() error {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	in, err := os.Open(h.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// without retention file is only read to find the latest entry
+	if h.retention <= 0 {
+		_, err = h.scan(in, time.Time{}, ioutil.Discard)
+		return err
+	}
+
+	tmp := h.file + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	dropped, err := h.scan(in, h.clock.Now().Add(-h.retention), out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || dropped == 0 {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, h.file)
+}
+
+// scan copies entries recorded since from to out and tells how many are dropped. Time of the latest entry is kept.
+func (h *History) scan(in io.Reader, from time.Time, out io.Writer) (int, error) {
+	dropped := 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		entry := struct {
+			At time.Time `json:"at"`
+		}{}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			if entry.At.Before(from) {
+				dropped++
+				continue
+			}
+			if entry.At.After(h.lastAt) {
+				h.lastAt = entry.At
+			}
+		}
+
+		_, err := out.Write(append(scanner.Bytes(), '\n'))
+		if err != nil {
+			return dropped, err
+		}
+	}
+
+	return dropped, scanner.Err()
+}
+
+func (h *History) append(entry HistoryEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(h.file), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(content, '\n'))
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Entries reads entries matching filter in order they were recorded. Entries are recorded and compacted
+// in time order, so reading stops at the first one past To.
+func (h *History) Entries(filter HistoryFilter) ([]HistoryEntry, error) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	result := make([]HistoryEntry, 0)
+
+	f, err := os.Open(h.file)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := HistoryEntry{}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode line %d of %s", line, h.file)
+		}
+
+		if !filter.To.IsZero() && !entry.At.Before(filter.To) {
+			break
+		}
+
+		if filter.Matches(entry) {
+			result = append(result, entry)
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+// MemoryHistory keeps history in memory, it does not survive restarts.
+type MemoryHistory struct {
+	clock   clock
+	entries []HistoryEntry
+	mux     sync.Mutex
+}
+
+func NewMemoryHistory(clock clock) *MemoryHistory {
+	return &MemoryHistory{
+		clock: clock,
+	}
+}
+
+func (h *MemoryHistory) Record(entry HistoryEntry) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	entry.At = h.clock.Now()
+	h.entries = append(h.entries, entry)
+}
+
+func (h *MemoryHistory) Entries(filter HistoryFilter) ([]HistoryEntry, error) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	result := make([]HistoryEntry, 0)
+	for _, entry := range h.entries {
+		if filter.Matches(entry) {
+			result = append(result, entry)
+		}
+	}
+
+	return result, nil
+}
+
+type historyCauseKey struct{}
+
+// historyCause is action and actor silence calls are made for
+type historyCause struct {
+	action string
+	actor  string
+}
+
+// withHistoryCause marks silence calls made with ctx as caused by action of actor.
+func withHistoryCause(ctx context.Context, action string, actor string) context.Context {
+	return context.WithValue(ctx, historyCauseKey{}, historyCause{action, actor})
+}
+
+func historyCauseFrom(ctx context.Context) historyCause {
+	cause, _ := ctx.Value(historyCauseKey{}).(historyCause)
+	return cause
+}
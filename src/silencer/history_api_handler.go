@@ -0,0 +1,112 @@
+package silencer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// HistoryFormatJSONL is format of history export, one entry per line
+const HistoryFormatJSONL = "jsonl"
+
+type historyReader interface {
+	Entries(filter HistoryFilter) ([]HistoryEntry, error)
+}
+
+// HistoryAPIHandler serves history of maintenances as JSON, or exports it as JSON lines.
+type HistoryAPIHandler struct {
+	history historyReader
+}
+
+func NewHistoryAPIHandler(history historyReader) *HistoryAPIHandler {
+	return &HistoryAPIHandler{history}
+}
+
+// Register mounts handlers under r, usually /api/v1.
+func (h *HistoryAPIHandler) Register(r chi.Router) {
+	r.Get("/history", h.List())
+}
+
+// List returns entries in order they were recorded. They are filtered by maintenance (identity or id), event,
+// action, actor, target, silenceId, from and to (RFC3339), limit keeps the latest entries.
+func (h *HistoryAPIHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseHistoryFilter(r)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				writeAPIError(w, http.StatusBadRequest, errors.Errorf("invalid limit %q", value))
+				return
+			}
+		}
+
+		entries, err := h.history.Entries(filter)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "json":
+			writeJSON(w, http.StatusOK, entries)
+		case HistoryFormatJSONL:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="history.jsonl"`)
+			w.WriteHeader(http.StatusOK)
+
+			encoder := json.NewEncoder(w)
+			for _, entry := range entries {
+				_ = encoder.Encode(entry)
+			}
+		default:
+			writeAPIError(w, http.StatusBadRequest, errors.Errorf("unknown format %q", format))
+		}
+	}
+}
+
+func parseHistoryFilter(r *http.Request) (HistoryFilter, error) {
+	query := r.URL.Query()
+	filter := HistoryFilter{
+		Event:     query.Get("event"),
+		Action:    query.Get("action"),
+		Actor:     query.Get("actor"),
+		Target:    query.Get("target"),
+		SilenceID: ActiveSilenceID(query.Get("silenceId")),
+	}
+
+	// maintenance could be gone already, so id is not looked up but turned into identity
+	if ref := query.Get("maintenance"); ref != "" {
+		filter.Maintenances = []MaintenanceHash{YamlMaintenance{ID: ref}.Identity()}
+		if hash, err := uuid.FromString(ref); err == nil {
+			filter.Maintenances = append(filter.Maintenances, MaintenanceHash(hash))
+		}
+	}
+
+	var err error
+	filter.From, err = parseTimeParam(r, "from", time.Time{})
+	if err != nil {
+		return HistoryFilter{}, err
+	}
+
+	filter.To, err = parseTimeParam(r, "to", time.Time{})
+	if err != nil {
+		return HistoryFilter{}, err
+	}
+
+	return filter, nil
+}
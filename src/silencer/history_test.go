@@ -0,0 +1,176 @@
+package silencer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryAPIHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silencer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sunday := time.Date(2021, 4, 11, 3, 0, 0, 0, time.UTC)
+	backup := YamlMaintenance{ID: "backup"}.Identity()
+	vacuum := YamlMaintenance{ID: "vacuum"}.Identity()
+
+	// history is appended across restarts
+	file := filepath.Join(dir, "history.jsonl")
+	NewHistory(file, 0, ClockMock{sunday}, logrus.New()).Record(HistoryEntry{
+		Event:       HistoryEventSilenceCreated,
+		Action:      HistoryActionSchedule,
+		Maintenance: backup,
+		Target:      DefaultTargetName,
+		SilenceID:   "silence",
+		Window:      &APIWindow{sunday, sunday.Add(time.Hour)},
+	})
+	history := NewHistory(file, 0, ClockMock{sunday.Add(20 * time.Minute)}, logrus.New())
+	history.Record(HistoryEntry{
+		Event:       HistoryEventManual,
+		Action:      OverrideActionEnd,
+		Maintenance: backup,
+		Actor:       "alice",
+	})
+	history.Record(HistoryEntry{
+		Event:       HistoryEventSilenceFailed,
+		Action:      HistoryActionSchedule,
+		Maintenance: vacuum,
+		Target:      DefaultTargetName,
+		Error:       "create silence: connection refused",
+	})
+
+	r := chi.NewRouter()
+	NewHistoryAPIHandler(history).Register(r)
+
+	list := func(query string) []HistoryEntry {
+		w := serveJSON(r, http.MethodGet, "/history?"+query, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		entries := make([]HistoryEntry, 0)
+		err := json.Unmarshal(w.Body.Bytes(), &entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return entries
+	}
+
+	assert.Len(t, list(""), 3)
+	assert.Len(t, list("maintenance=backup"), 2)
+	assert.Len(t, list("maintenance="+vacuum.String()), 1)
+	assert.Len(t, list("event=silence_failed"), 1)
+	assert.Len(t, list("limit=1"), 1)
+
+	at := url.Values{
+		"from": []string{sunday.Format(time.RFC3339)},
+		"to":   []string{sunday.Add(time.Minute).Format(time.RFC3339)},
+	}
+	entries := list(at.Encode())
+	assert.Len(t, entries, 1)
+	assert.Equal(t, ActiveSilenceID("silence"), entries[0].SilenceID)
+
+	entries = list("maintenance=backup&actor=alice")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, OverrideActionEnd, entries[0].Action)
+
+	w := serveJSON(r, http.MethodGet, "/history?format=jsonl&target=default", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := 0
+	for scanner := bufio.NewScanner(w.Body); scanner.Scan(); lines++ {
+		entry := HistoryEntry{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+	}
+	assert.Equal(t, 2, lines)
+
+	w = serveJSON(r, http.MethodGet, "/history?from=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHistory_Retention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silencer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sunday := time.Date(2021, 4, 11, 3, 0, 0, 0, time.UTC)
+	file := filepath.Join(dir, "history.jsonl")
+	record := func(at time.Time, actor string) {
+		NewHistory(file, 7*24*time.Hour, ClockMock{at}, logrus.New()).Record(HistoryEntry{
+			Event:  HistoryEventManual,
+			Action: OverrideActionStart,
+			Actor:  actor,
+		})
+	}
+
+	record(sunday, "alice")
+	record(sunday.Add(24*time.Hour), "bob")
+	record(sunday.Add(8*24*time.Hour), "carol")
+
+	history := NewHistory(file, 7*24*time.Hour, ClockMock{sunday.Add(8 * 24 * time.Hour)}, logrus.New())
+	entries, err := history.Entries(HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3, "entries are dropped by compaction only")
+
+	history.Start()
+	defer func() {
+		_ = history.Stop(context.Background())
+	}()
+
+	entries, err = history.Entries(HistoryFilter{})
+	assert.NoError(t, err)
+	actors := make([]string, 0, len(entries))
+	for _, e := range entries {
+		actors = append(actors, e.Actor)
+	}
+	assert.Equal(t, []string{"bob", "carol"}, actors, "entry older than retention is dropped")
+
+	entries, err = history.Entries(HistoryFilter{To: sunday.Add(2 * 24 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestHistory_KeepsTimeOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silencer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sunday := time.Date(2021, 4, 11, 3, 0, 0, 0, time.UTC)
+	file := filepath.Join(dir, "history.jsonl")
+	NewHistory(file, 0, ClockMock{sunday}, logrus.New()).Record(HistoryEntry{Event: HistoryEventManual, Actor: "alice"})
+
+	// clock went back after restart
+	history := NewHistory(file, 0, ClockMock{sunday.Add(-time.Hour)}, logrus.New())
+	history.Start()
+	defer func() {
+		_ = history.Stop(context.Background())
+	}()
+	history.Record(HistoryEntry{Event: HistoryEventManual, Actor: "bob"})
+
+	entries, err := history.Entries(HistoryFilter{})
+	assert.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, sunday, entries[1].At.UTC(), "entry is not recorded before the latest one")
+
+	entries, err = history.Entries(HistoryFilter{To: sunday.Add(time.Second)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
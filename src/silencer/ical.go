@@ -0,0 +1,307 @@
+package silencer
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// icalComponent is component of iCalendar object, e.g. VCALENDAR or VEVENT, as defined by RFC 5545.
+type icalComponent struct {
+	Name       string
+	Properties []icalProperty
+	Components []icalComponent
+}
+
+// icalProperty is content line of component, parameter names and property name are upper case.
+type icalProperty struct {
+	Name   string
+	Params map[string][]string
+	Value  string
+}
+
+// Param returns the first value of parameter, it is empty when there is none.
+func (p icalProperty) Param(name string) string {
+	if values := p.Params[name]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Property returns the first property of name.
+func (c icalComponent) Property(name string) (icalProperty, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return icalProperty{}, false
+}
+
+// All returns every property of name in order they are listed.
+func (c icalComponent) All(name string) []icalProperty {
+	result := make([]icalProperty, 0)
+	for _, p := range c.Properties {
+		if p.Name == name {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+// Text returns unescaped value of the first property of name.
+func (c icalComponent) Text(name string) string {
+	p, _ := c.Property(name)
+	return unescapeICalText(p.Value)
+}
+
+// parseICal parses iCalendar object, content of .ics file is usually single VCALENDAR.
+// Lines are unfolded, both CRLF and LF line breaks are accepted.
+func parseICal(content []byte) ([]icalComponent, error) {
+	lines, err := unfoldICalLines(content)
+	if err != nil {
+		return nil, err
+	}
+
+	root := icalComponent{}
+	stack := []*icalComponent{&root}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		p, err := parseICalLine(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i+1)
+		}
+
+		current := stack[len(stack)-1]
+		switch p.Name {
+		case "BEGIN":
+			current.Components = append(current.Components, icalComponent{Name: strings.ToUpper(p.Value)})
+			stack = append(stack, &current.Components[len(current.Components)-1])
+		case "END":
+			if len(stack) == 1 || current.Name != strings.ToUpper(p.Value) {
+				return nil, errors.Errorf("line %d: unexpected END:%s", i+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 1 {
+				return nil, errors.Errorf("line %d: property %s outside of component", i+1, p.Name)
+			}
+			current.Properties = append(current.Properties, p)
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, errors.Errorf("component %s is not ended", stack[len(stack)-1].Name)
+	}
+
+	return root.Components, nil
+}
+
+// unfoldICalLines joins continuation lines, which start with space or tab, to preceding line.
+func unfoldICalLines(content []byte) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseICalLine splits content line into name, parameters and value. Parameter values could be quoted,
+// so colons and semicolons inside quotes do not end them.
+func parseICalLine(line string) (icalProperty, error) {
+	p := icalProperty{Params: make(map[string][]string)}
+
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return icalProperty{}, errors.Errorf("invalid content line %q", line)
+	}
+	p.Name = strings.ToUpper(line[:end])
+
+	rest := line[end:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.Index(rest, "=")
+		if eq <= 0 {
+			return icalProperty{}, errors.Errorf("invalid parameter of %s", p.Name)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		for {
+			var value string
+			if strings.HasPrefix(rest, `"`) {
+				closing := strings.Index(rest[1:], `"`)
+				if closing < 0 {
+					return icalProperty{}, errors.Errorf("unterminated quoted parameter %s of %s", name, p.Name)
+				}
+				value, rest = rest[1:closing+1], rest[closing+2:]
+			} else {
+				valueEnd := strings.IndexAny(rest, ",;:")
+				if valueEnd < 0 {
+					return icalProperty{}, errors.Errorf("missing value of %s", p.Name)
+				}
+				value, rest = rest[:valueEnd], rest[valueEnd:]
+			}
+			p.Params[name] = append(p.Params[name], value)
+
+			if !strings.HasPrefix(rest, ",") {
+				break
+			}
+			rest = rest[1:]
+		}
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return icalProperty{}, errors.Errorf("missing value of %s", p.Name)
+	}
+	p.Value = rest[1:]
+
+	return p, nil
+}
+
+var icalTextUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescapeICalText(value string) string {
+	return icalTextUnescaper.Replace(value)
+}
+
+// splitICalText splits list of TEXT values, e.g. CATEGORIES, on commas, which are not escaped.
+func splitICalText(value string) []string {
+	result := make([]string, 0)
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			result = append(result, unescapeICalText(value[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(result, unescapeICalText(value[start:]))
+}
+
+// Layouts of DATE and DATE-TIME values
+const (
+	icalDateLayout      = "20060102"
+	icalLocalTimeLayout = "20060102T150405"
+	icalUTCTimeLayout   = "20060102T150405Z"
+)
+
+// parseICalTimes parses comma separated DATE or DATE-TIME values of property. Time is in UTC when it ends with Z,
+// in TZID location when it is given, in defaultLocation otherwise. isDate tells whether values are dates.
+func parseICalTimes(p icalProperty, defaultLocation *time.Location) (times []time.Time, isDate bool, err error) {
+	location := defaultLocation
+	if tzid := p.Param("TZID"); tzid != "" {
+		location, err = time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "unknown TZID of %s", p.Name)
+		}
+	}
+
+	switch valueType := strings.ToUpper(p.Param("VALUE")); valueType {
+	case "DATE":
+		isDate = true
+	case "", "DATE-TIME":
+	default:
+		return nil, false, errors.Errorf("%s of type %s is not supported", p.Name, valueType)
+	}
+
+	for _, value := range strings.Split(p.Value, ",") {
+		var t time.Time
+		switch {
+		case isDate:
+			t, err = time.ParseInLocation(icalDateLayout, value, location)
+		case strings.HasSuffix(value, "Z"):
+			t, err = time.Parse(icalUTCTimeLayout, value)
+		default:
+			t, err = time.ParseInLocation(icalLocalTimeLayout, value, location)
+		}
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "invalid %s", p.Name)
+		}
+
+		times = append(times, t)
+	}
+
+	return times, isDate, nil
+}
+
+// parseICalDuration parses DURATION value, e.g. PT1H30M or P1W. Days are 24 hours long.
+func parseICalDuration(value string) (time.Duration, error) {
+	s := value
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) == 1 {
+		return 0, errors.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	units := map[byte]time.Duration{
+		'W': 7 * 24 * time.Hour,
+		'D': 24 * time.Hour,
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+	}
+
+	var result time.Duration
+	inTime, hasUnits := false, false
+	number := -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == 'T':
+			inTime = true
+		case c >= '0' && c <= '9':
+			if number < 0 {
+				number = 0
+			}
+			number = number*10 + int(c-'0')
+		default:
+			unit, ok := units[c]
+			// M is month before T, which duration of event could not have
+			if !ok || number < 0 || (c == 'M' && !inTime) || ((c == 'H' || c == 'S') && !inTime) {
+				return 0, errors.Errorf("invalid duration %q", value)
+			}
+			result += time.Duration(number) * unit
+			number, hasUnits = -1, true
+		}
+	}
+
+	if number >= 0 || !hasUnits {
+		return 0, errors.Errorf("invalid duration %q", value)
+	}
+
+	return sign * result, nil
+}
@@ -0,0 +1,72 @@
+package silencer
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	defaultCreatedBy = "maintenance service"
+	instanceMarker   = "instance="
+)
+
+// Instance identifies silencer deployment. Silences belong to the instance, which created them,
+// so several instances could share one Alertmanager without touching each other's silences.
+type Instance struct {
+	name string
+}
+
+func NewInstance(name string) (Instance, error) {
+	if strings.ContainsAny(name, " \t\n/") {
+		return Instance{}, errors.Errorf("invalid instance name %q: whitespaces and slashes are not allowed", name)
+	}
+
+	return Instance{name}, nil
+}
+
+func (i Instance) Name() string {
+	return i.name
+}
+
+// CreatedBy is silence author. Unnamed instance keeps the author used before instances were introduced.
+func (i Instance) CreatedBy() string {
+	if i.name == "" {
+		return defaultCreatedBy
+	}
+
+	return defaultCreatedBy + "/" + i.name
+}
+
+// Comment is silence comment: maintenance identity followed by ownership marker.
+func (i Instance) Comment(hash MaintenanceHash) string {
+	if i.name == "" {
+		return hash.String()
+	}
+
+	return hash.String() + " " + instanceMarker + i.name
+}
+
+// ParseComment extracts maintenance identity from silence comment.
+// It reports false, when silence is not owned by the instance.
+func (i Instance) ParseComment(comment string) (MaintenanceHash, bool) {
+	fields := strings.Fields(strings.SplitN(comment, "\n", 2)[0])
+	if len(fields) == 0 {
+		return MaintenanceHash{}, false
+	}
+
+	hash, err := uuid.FromString(fields[0])
+	if err != nil {
+		return MaintenanceHash{}, false
+	}
+
+	owner := ""
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, instanceMarker) {
+			owner = strings.TrimPrefix(f, instanceMarker)
+		}
+	}
+
+	return MaintenanceHash(hash), owner == i.name
+}
@@ -8,21 +8,87 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
+// MaintenanceHash identifies maintenance. It is derived from user-assigned id,
+// or from maintenance content when id is not set.
 type MaintenanceHash uuid.UUID
 
 func (hash MaintenanceHash) String() string {
 	return uuid.UUID(hash).String()
 }
 
+func (hash MaintenanceHash) MarshalText() ([]byte, error) {
+	return uuid.UUID(hash).MarshalText()
+}
+
+func (hash *MaintenanceHash) UnmarshalText(text []byte) error {
+	return (*uuid.UUID)(hash).UnmarshalText(text)
+}
+
 type Maintenance struct {
 	Hash     MaintenanceHash
 	Matchers models.Matchers
 	Schedule cron.Schedule
 	Duration time.Duration
+	Location *time.Location
+	// ID is optional user-assigned identity
+	ID string
+	// ContentHash is the content based identity, silences created before id was assigned are bound to it
+	ContentHash MaintenanceHash
+	// Alertmanager is name of targets maintenance is silenced in
+	Alertmanager string
+	// Source tells where maintenance is defined: config file or API
+	Source string
 }
 
 func (m Maintenance) ActiveAt(t time.Time) (bool, time.Time) {
+	if m.FinishedAt(t) {
+		return false, time.Time{}
+	}
+
 	durationTimeAgo := t.Add(-m.Duration)
 	startAt := m.Schedule.Next(durationTimeAgo)
 	return startAt.Before(t), startAt
 }
+
+func (m Maintenance) IsOneOff() bool {
+	_, ok := baseSchedule(m.Schedule).(OneOffSchedule)
+	return ok
+}
+
+// FinishedAt reports whether the last window of maintenance is over at t, postponed window included.
+// It is one-off window or the last occurrence of recurrence bounded by COUNT or UNTIL.
+// Cron schedules never finish.
+func (m Maintenance) FinishedAt(t time.Time) bool {
+	switch baseSchedule(m.Schedule).(type) {
+	case OneOffSchedule, RecurrenceSetSchedule, RecurrenceSchedule:
+		return m.Schedule.Next(t.Add(-m.Duration)).IsZero()
+	default:
+		return false
+	}
+}
+
+// maxWindows limits amount of windows computed at once, so frequent schedules could not exhaust memory.
+const maxWindows = 10000
+
+type Window struct {
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// Contains tells whether window is open at t.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.StartAt) && t.Before(w.EndAt)
+}
+
+// Windows returns windows of maintenance starting within (from, to].
+func (m Maintenance) Windows(from, to time.Time) []Window {
+	result := make([]Window, 0)
+	for startAt := m.Schedule.Next(from); !startAt.IsZero() && !startAt.After(to); startAt = m.Schedule.Next(startAt) {
+		result = append(result, Window{startAt, startAt.Add(m.Duration)})
+		if len(result) >= maxWindows {
+			break
+		}
+	}
+
+	return result
+}
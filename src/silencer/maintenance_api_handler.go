@@ -0,0 +1,474 @@
+package silencer
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+type runtimeMaintenances interface {
+	Create(m YamlMaintenance) (YamlMaintenance, error)
+	Update(id string, m YamlMaintenance) (YamlMaintenance, error)
+	Delete(id string, actor string) error
+}
+
+type occurrenceExceptions interface {
+	Skip(hash MaintenanceHash, scheduledAt time.Time, actor string) (OccurrenceException, error)
+	Postpone(hash MaintenanceHash, scheduledAt time.Time, startAt time.Time, actor string) (OccurrenceException, error)
+	Restore(hash MaintenanceHash, scheduledAt time.Time, actor string) error
+}
+
+type maintenanceWindows interface {
+	StartNow(hash MaintenanceHash, actor string) error
+	EndNow(hash MaintenanceHash, actor string) error
+	Extend(hash MaintenanceHash, endAt time.Time, actor string) error
+}
+
+// MaintenanceAPIHandler serves maintenances and their occurrences as JSON, manages runtime maintenances,
+// windows and occurrence exceptions of all maintenances.
+type MaintenanceAPIHandler struct {
+	watchedMaintenanceStorage watchedMaintenanceStorage
+	yamlMaintenanceIndex      yamlMaintenanceIndex
+	runtimeMaintenances       runtimeMaintenances
+	maintenanceWindows        maintenanceWindows
+	occurrenceExceptions      occurrenceExceptions
+	clock                     clock
+}
+
+func NewMaintenanceAPIHandler(
+	watchedMaintenanceStorage watchedMaintenanceStorage,
+	yamlMaintenanceIndex yamlMaintenanceIndex,
+	runtimeMaintenances runtimeMaintenances,
+	maintenanceWindows maintenanceWindows,
+	occurrenceExceptions occurrenceExceptions,
+	clock clock,
+) *MaintenanceAPIHandler {
+	return &MaintenanceAPIHandler{
+		watchedMaintenanceStorage,
+		yamlMaintenanceIndex,
+		runtimeMaintenances,
+		maintenanceWindows,
+		occurrenceExceptions,
+		clock,
+	}
+}
+
+// Register mounts handlers under r, usually /api/v1.
+func (h *MaintenanceAPIHandler) Register(r chi.Router) {
+	r.Get("/maintenances", h.List())
+	r.Post("/maintenances", h.Create())
+	r.Get("/maintenances/{id}", h.Get())
+	r.Put("/maintenances/{id}", h.Update())
+	r.Delete("/maintenances/{id}", h.Delete())
+	r.Get("/maintenances/{id}/occurrences", h.Occurrences())
+	r.Post("/maintenances/{id}/start", h.Start())
+	r.Post("/maintenances/{id}/end", h.End())
+	r.Post("/maintenances/{id}/extend", h.Extend())
+	r.Get("/maintenances/{id}/exceptions", h.Exceptions())
+	r.Post("/maintenances/{id}/exceptions", h.AddException())
+	r.Delete("/maintenances/{id}/exceptions", h.DeleteException())
+}
+
+func (h *MaintenanceAPIHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		maintenances := h.watchedMaintenanceStorage.WatchedMaintenances()
+
+		result := make([]APIMaintenance, len(maintenances))
+		for i, m := range maintenances {
+			result[i] = NewAPIMaintenance(m, h.yamlMaintenanceIndex.Get(m.Maintenance.Hash))
+		}
+
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func (h *MaintenanceAPIHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.find(chi.URLParam(r, "id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, NewAPIMaintenance(m, h.yamlMaintenanceIndex.Get(m.Maintenance.Hash)))
+	}
+}
+
+// Create adds runtime maintenance.
+func (h *MaintenanceAPIHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		spec, err := decodeMaintenanceSpec(r)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		created, err := h.runtimeMaintenances.Create(spec.YamlMaintenance())
+		if err != nil {
+			writeRuntimeMaintenanceError(w, err)
+			return
+		}
+
+		h.writeMaintenance(w, http.StatusCreated, created.Identity())
+	}
+}
+
+// Update replaces runtime maintenance, maintenances of config file could not be changed via API.
+func (h *MaintenanceAPIHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.findRuntime(w, chi.URLParam(r, "id"))
+		if !ok {
+			return
+		}
+
+		spec, err := decodeMaintenanceSpec(r)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		updated, err := h.runtimeMaintenances.Update(m.Maintenance.ID, spec.YamlMaintenance())
+		if err != nil {
+			writeRuntimeMaintenanceError(w, err)
+			return
+		}
+
+		h.writeMaintenance(w, http.StatusOK, updated.Identity())
+	}
+}
+
+// Delete removes runtime maintenance along with its silences, author is required in query.
+func (h *MaintenanceAPIHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.findRuntime(w, chi.URLParam(r, "id"))
+		if !ok {
+			return
+		}
+
+		author := r.URL.Query().Get("author")
+		if author == "" {
+			writeAPIError(w, http.StatusBadRequest, errors.New("author is required"))
+			return
+		}
+
+		err := h.runtimeMaintenances.Delete(m.Maintenance.ID, author)
+		if err != nil {
+			writeRuntimeMaintenanceError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *MaintenanceAPIHandler) writeMaintenance(w http.ResponseWriter, status int, hash MaintenanceHash) {
+	m, ok := h.find(hash.String())
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, errors.New("maintenance is not scheduled"))
+		return
+	}
+
+	writeJSON(w, status, NewAPIMaintenance(m, h.yamlMaintenanceIndex.Get(hash)))
+}
+
+// findRuntime looks runtime maintenance up and writes error response when it is not one.
+func (h *MaintenanceAPIHandler) findRuntime(w http.ResponseWriter, ref string) (WatchedMaintenance, bool) {
+	m, ok := h.find(ref)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+		return WatchedMaintenance{}, false
+	}
+
+	if m.Maintenance.Source != MaintenanceSourceAPI {
+		writeAPIError(w, http.StatusConflict, errors.Errorf("maintenance is defined by %s", m.Maintenance.Source))
+		return WatchedMaintenance{}, false
+	}
+
+	return m, true
+}
+
+func decodeMaintenanceSpec(r *http.Request) (APIMaintenanceSpec, error) {
+	spec := APIMaintenanceSpec{}
+	err := json.NewDecoder(r.Body).Decode(&spec)
+	if err != nil {
+		return APIMaintenanceSpec{}, errors.Wrap(err, "invalid maintenance")
+	}
+
+	if spec.Author == "" {
+		return APIMaintenanceSpec{}, errors.New("author is required")
+	}
+
+	return spec, nil
+}
+
+func writeRuntimeMaintenanceError(w http.ResponseWriter, err error) {
+	cause := errors.Cause(err)
+	if cause == ErrMaintenanceNotFound {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if _, ok := cause.(InvalidMaintenanceError); ok {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeAPIError(w, http.StatusInternalServerError, err)
+}
+
+// Start opens window of maintenance right away.
+func (h *MaintenanceAPIHandler) Start() http.HandlerFunc {
+	return h.windowAction(func(m WatchedMaintenance, spec APIWindowActionSpec) error {
+		return h.maintenanceWindows.StartNow(m.Maintenance.Hash, spec.Author)
+	})
+}
+
+// End closes the current window of maintenance early.
+func (h *MaintenanceAPIHandler) End() http.HandlerFunc {
+	return h.windowAction(func(m WatchedMaintenance, spec APIWindowActionSpec) error {
+		return h.maintenanceWindows.EndNow(m.Maintenance.Hash, spec.Author)
+	})
+}
+
+// Extend moves end of the current window of maintenance to endAt or by duration.
+func (h *MaintenanceAPIHandler) Extend() http.HandlerFunc {
+	return h.windowAction(func(m WatchedMaintenance, spec APIWindowActionSpec) error {
+		if m.Window.StartAt.IsZero() {
+			return ErrMaintenanceNotActive
+		}
+
+		var endAt time.Time
+		switch {
+		case spec.EndAt != nil && spec.Duration != "":
+			return InvalidMaintenanceError{errors.New("endAt and duration are mutually exclusive")}
+		case spec.EndAt != nil:
+			endAt = *spec.EndAt
+		case spec.Duration != "":
+			d, err := time.ParseDuration(spec.Duration)
+			if err != nil {
+				return InvalidMaintenanceError{errors.Wrap(err, "invalid duration")}
+			}
+			endAt = m.Window.EndAt.Add(d)
+		default:
+			return InvalidMaintenanceError{errors.New("either endAt or duration is required")}
+		}
+
+		return h.maintenanceWindows.Extend(m.Maintenance.Hash, endAt, spec.Author)
+	})
+}
+
+// windowAction decodes request, runs action for maintenance and responds with the maintenance.
+func (h *MaintenanceAPIHandler) windowAction(action func(m WatchedMaintenance, spec APIWindowActionSpec) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.find(chi.URLParam(r, "id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+			return
+		}
+
+		spec := APIWindowActionSpec{}
+		err := json.NewDecoder(r.Body).Decode(&spec)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, errors.Wrap(err, "invalid request"))
+			return
+		}
+
+		if spec.Author == "" {
+			writeAPIError(w, http.StatusBadRequest, errors.New("author is required"))
+			return
+		}
+
+		err = action(m, spec)
+		if err != nil {
+			writeWindowActionError(w, err)
+			return
+		}
+
+		h.writeMaintenance(w, http.StatusOK, m.Maintenance.Hash)
+	}
+}
+
+func writeWindowActionError(w http.ResponseWriter, err error) {
+	switch errors.Cause(err) {
+	case ErrMaintenanceNotFound:
+		writeAPIError(w, http.StatusNotFound, err)
+	case ErrMaintenanceActive, ErrMaintenanceNotActive, ErrMaintenanceFinished:
+		writeAPIError(w, http.StatusConflict, err)
+	case ErrEndNotInFuture:
+		writeAPIError(w, http.StatusBadRequest, err)
+	default:
+		writeRuntimeMaintenanceError(w, err)
+	}
+}
+
+// Occurrences lists windows overlapping from and to (RFC3339), the next week is listed by default.
+// Range is limited to 92 days.
+func (h *MaintenanceAPIHandler) Occurrences() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.find(chi.URLParam(r, "id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+			return
+		}
+
+		now := h.clock.Now()
+		from, err := parseTimeParam(r, "from", now)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		to, err := parseTimeParam(r, "to", from.Add(defaultOccurrencesRange))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if to.Before(from) {
+			writeAPIError(w, http.StatusBadRequest, errors.New("to is before from"))
+			return
+		}
+
+		if to.Sub(from) > maxOccurrencesRange {
+			writeAPIError(w, http.StatusBadRequest, errors.Errorf("range is longer than %s", maxOccurrencesRange))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, Occurrences(m.Maintenance, m.Exceptions, from, to, now))
+	}
+}
+
+// Exceptions lists skipped and postponed occurrences, which are not over yet.
+func (h *MaintenanceAPIHandler) Exceptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.find(chi.URLParam(r, "id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+			return
+		}
+
+		result := make([]APIException, len(m.Exceptions))
+		for i, e := range m.Exceptions {
+			result[i] = NewAPIException(e)
+		}
+
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// AddException skips or postpones upcoming occurrence, exception of the same occurrence is replaced.
+func (h *MaintenanceAPIHandler) AddException() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.find(chi.URLParam(r, "id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+			return
+		}
+
+		spec := APIExceptionSpec{}
+		err := json.NewDecoder(r.Body).Decode(&spec)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, errors.Wrap(err, "invalid exception"))
+			return
+		}
+
+		if spec.Author == "" {
+			writeAPIError(w, http.StatusBadRequest, errors.New("author is required"))
+			return
+		}
+
+		var exception OccurrenceException
+		switch spec.Action {
+		case ExceptionActionSkip:
+			exception, err = h.occurrenceExceptions.Skip(m.Maintenance.Hash, spec.ScheduledAt, spec.Author)
+		case ExceptionActionPostpone:
+			if spec.StartAt == nil {
+				writeAPIError(w, http.StatusBadRequest, errors.New("startAt is required"))
+				return
+			}
+			exception, err = h.occurrenceExceptions.Postpone(m.Maintenance.Hash, spec.ScheduledAt, *spec.StartAt, spec.Author)
+		default:
+			writeAPIError(w, http.StatusBadRequest, errors.Errorf("unknown action %q", spec.Action))
+			return
+		}
+		if err != nil {
+			writeExceptionError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, NewAPIException(exception))
+	}
+}
+
+// DeleteException restores occurrence scheduled at scheduledAt (RFC3339), author is required in query.
+func (h *MaintenanceAPIHandler) DeleteException() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := h.find(chi.URLParam(r, "id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, ErrMaintenanceNotFound)
+			return
+		}
+
+		author := r.URL.Query().Get("author")
+		if author == "" {
+			writeAPIError(w, http.StatusBadRequest, errors.New("author is required"))
+			return
+		}
+
+		if r.URL.Query().Get("scheduledAt") == "" {
+			writeAPIError(w, http.StatusBadRequest, errors.New("scheduledAt is required"))
+			return
+		}
+
+		scheduledAt, err := parseTimeParam(r, "scheduledAt", time.Time{})
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = h.occurrenceExceptions.Restore(m.Maintenance.Hash, scheduledAt, author)
+		if err != nil {
+			writeExceptionError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeExceptionError(w http.ResponseWriter, err error) {
+	switch errors.Cause(err) {
+	case ErrOccurrenceNotFound, ErrExceptionNotFound:
+		writeAPIError(w, http.StatusNotFound, err)
+	default:
+		writeRuntimeMaintenanceError(w, err)
+	}
+}
+
+// find looks maintenance up by identity or by user-assigned id.
+func (h *MaintenanceAPIHandler) find(ref string) (WatchedMaintenance, bool) {
+	for _, m := range h.watchedMaintenanceStorage.WatchedMaintenances() {
+		if m.Maintenance.Hash.String() == ref || (m.Maintenance.ID != "" && m.Maintenance.ID == ref) {
+			return m, true
+		}
+	}
+
+	return WatchedMaintenance{}, false
+}
+
+func parseTimeParam(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid %s", name)
+	}
+
+	return t, nil
+}
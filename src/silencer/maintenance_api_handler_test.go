@@ -0,0 +1,150 @@
+package silencer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceAPIHandler(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+
+	maintenance := YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+	m := MustMaintenance(ParseMaintenance(maintenance))
+
+	storage := watchedMaintenanceStorageMock{
+		items: []WatchedMaintenance{
+			{
+				Maintenance: m,
+				Next:        m.Schedule.Next(now),
+				IsActive:    true,
+				Window:      Window{now.Add(-10 * time.Minute), now.Add(50 * time.Minute)},
+				Targets:     []WatchedTarget{{Name: DefaultTargetName, IsActive: true, SilenceID: "silence"}},
+			},
+		},
+	}
+
+	r := chi.NewRouter()
+	yamlMaintenanceIndex := BuildYamlMaintenanceIndex([]YamlMaintenance{maintenance})
+	NewMaintenanceAPIHandler(storage, yamlMaintenanceIndex, nil, nil, nil, ClockMock{now}).Register(r)
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedBody   interface{}
+		body           interface{}
+	}{
+		{
+			name:           "list",
+			url:            "/maintenances",
+			expectedStatus: http.StatusOK,
+			body:           &[]APIMaintenance{},
+			expectedBody: &[]APIMaintenance{
+				{
+					Identity:     maintenance.Identity().String(),
+					ID:           "backup",
+					Matchers:     []APIMatcher{{Name: "alertname", Value: "backup"}},
+					Schedule:     "0 3 * * *",
+					Duration:     "1h0m0s",
+					Timezone:     "UTC",
+					Alertmanager: DefaultTargetName,
+					Source:       MaintenanceSourceFile,
+					IsActive:     true,
+					SilenceID:    "silence",
+					Window: &APIWindow{
+						StartAt: time.Date(2021, 4, 7, 3, 0, 0, 0, time.UTC),
+						EndAt:   time.Date(2021, 4, 7, 4, 0, 0, 0, time.UTC),
+					},
+					Next:    timePtr(time.Date(2021, 4, 8, 3, 0, 0, 0, time.UTC)),
+					Targets: []APITarget{{Name: DefaultTargetName, IsActive: true, SilenceID: "silence"}},
+				},
+			},
+		},
+		{
+			name:           "get by id",
+			url:            "/maintenances/backup",
+			expectedStatus: http.StatusOK,
+			body:           &APIMaintenance{},
+		},
+		{
+			name:           "get by identity",
+			url:            "/maintenances/" + maintenance.Identity().String(),
+			expectedStatus: http.StatusOK,
+			body:           &APIMaintenance{},
+		},
+		{
+			name:           "unknown maintenance",
+			url:            "/maintenances/unknown",
+			expectedStatus: http.StatusNotFound,
+			body:           &APIError{},
+			expectedBody:   &APIError{"maintenance not found"},
+		},
+		{
+			name:           "occurrences",
+			url:            "/maintenances/backup/occurrences?from=2021-04-07T03:30:00Z&to=2021-04-09T03:00:00Z",
+			expectedStatus: http.StatusOK,
+			body:           &[]APIOccurrence{},
+			expectedBody: &[]APIOccurrence{
+				{
+					StartAt:  time.Date(2021, 4, 7, 3, 0, 0, 0, time.UTC),
+					EndAt:    time.Date(2021, 4, 7, 4, 0, 0, 0, time.UTC),
+					IsActive: true,
+				},
+				{
+					StartAt: time.Date(2021, 4, 8, 3, 0, 0, 0, time.UTC),
+					EndAt:   time.Date(2021, 4, 8, 4, 0, 0, 0, time.UTC),
+				},
+				{
+					StartAt: time.Date(2021, 4, 9, 3, 0, 0, 0, time.UTC),
+					EndAt:   time.Date(2021, 4, 9, 4, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			name:           "invalid occurrences range",
+			url:            "/maintenances/backup/occurrences?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			body:           &APIError{},
+		},
+		{
+			name:           "too long occurrences range",
+			url:            "/maintenances/backup/occurrences?from=2021-04-07T00:00:00Z&to=2031-04-07T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+			body:           &APIError{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			err := json.Unmarshal(w.Body.Bytes(), tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.expectedBody != nil {
+				assert.Equal(t, tc.expectedBody, tc.body)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
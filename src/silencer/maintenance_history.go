@@ -0,0 +1,38 @@
+package silencer
+
+import (
+	"context"
+)
+
+// recordSilence adds silence call made for maintenance in target to history, along with action and actor of ctx.
+// Failed call is recorded as silence_failed, zero window is omitted.
+func (s *MaintenanceService) recordSilence(
+	ctx context.Context,
+	t *targetState,
+	event string,
+	hash MaintenanceHash,
+	silenceID ActiveSilenceID,
+	w Window,
+	err error,
+) {
+	cause := historyCauseFrom(ctx)
+	entry := HistoryEntry{
+		Event:       event,
+		Action:      cause.action,
+		Maintenance: hash,
+		Target:      t.Name(),
+		SilenceID:   silenceID,
+		Actor:       cause.actor,
+	}
+
+	if !w.StartAt.IsZero() {
+		entry.Window = &APIWindow{w.StartAt, w.EndAt}
+	}
+
+	if err != nil {
+		entry.Event = HistoryEventSilenceFailed
+		entry.Error = historyOperations[event] + ": " + err.Error()
+	}
+
+	s.history.Record(entry)
+}
@@ -0,0 +1,99 @@
+package silencer
+
+import (
+	"time"
+)
+
+// Actions, which override maintenance window
+const (
+	OverrideActionStart  = "start"
+	OverrideActionEnd    = "end"
+	OverrideActionExtend = "extend"
+)
+
+// WindowOverride is manual change of maintenance window: started ahead of schedule, ended early or extended.
+// While it lasts, Window replaces scheduled windows starting before Until.
+type WindowOverride struct {
+	Action string
+	Actor  string
+	At     time.Time
+	Window Window
+	Until  time.Time
+}
+
+// newWindowOverride creates override of window w. It lasts until every scheduled window it overlaps is over,
+// so none of them is reopened after w ends.
+func newWindowOverride(m Maintenance, action, actor string, w Window, previous *WindowOverride, now time.Time) WindowOverride {
+	until := w.EndAt
+	if previous != nil && previous.Until.After(until) {
+		until = previous.Until
+	}
+
+	for _, scheduled := range m.Windows(now.Add(-m.Duration), w.EndAt) {
+		if scheduled.StartAt.Before(w.EndAt) && scheduled.EndAt.After(until) {
+			until = scheduled.EndAt
+		}
+	}
+
+	return WindowOverride{
+		Action: action,
+		Actor:  actor,
+		At:     now,
+		Window: w,
+		Until:  until,
+	}
+}
+
+// LastsAt tells whether override is in effect at t.
+func (o *WindowOverride) LastsAt(t time.Time) bool {
+	return o != nil && t.Before(o.Until)
+}
+
+// currentWindow returns window of maintenance open at now, override takes precedence over schedule while it lasts.
+func currentWindow(m Maintenance, o *WindowOverride, now time.Time) (Window, bool) {
+	if o.LastsAt(now) {
+		return o.Window, o.Window.Contains(now)
+	}
+
+	isActive, startAt := m.ActiveAt(now)
+	if !isActive {
+		return Window{}, false
+	}
+
+	return Window{startAt, startAt.Add(m.Duration)}, true
+}
+
+// windowChange chooses window replacing the current one, isActive tells whether current window is open now.
+type windowChange func(m Maintenance, current Window, isActive bool, now time.Time) (Window, error)
+
+func startNow(m Maintenance, _ Window, isActive bool, now time.Time) (Window, error) {
+	if isActive {
+		return Window{}, ErrMaintenanceActive
+	}
+	if m.FinishedAt(now) {
+		return Window{}, ErrMaintenanceFinished
+	}
+
+	return Window{now, now.Add(m.Duration)}, nil
+}
+
+func endNow(_ Maintenance, current Window, isActive bool, now time.Time) (Window, error) {
+	if !isActive {
+		return Window{}, ErrMaintenanceNotActive
+	}
+
+	return Window{current.StartAt, now}, nil
+}
+
+func extendTo(endAt time.Time) windowChange {
+	return func(_ Maintenance, current Window, isActive bool, now time.Time) (Window, error) {
+		if !isActive {
+			return Window{}, ErrMaintenanceNotActive
+		}
+		if !endAt.After(now) {
+			return Window{}, ErrEndNotInFuture
+		}
+
+		return Window{current.StartAt, endAt}, nil
+	}
+}
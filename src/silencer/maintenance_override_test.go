@@ -0,0 +1,79 @@
+package silencer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentWindow(t *testing.T) {
+	m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 4, 7, hour, minute, 0, 0, time.UTC)
+	}
+	startedAhead := newWindowOverride(m, OverrideActionStart, "alice", Window{at(2, 30), at(3, 30)}, nil, at(2, 30))
+	endedEarly := newWindowOverride(m, OverrideActionEnd, "alice", Window{at(3, 0), at(3, 20)}, nil, at(3, 20))
+	extended := newWindowOverride(m, OverrideActionExtend, "alice", Window{at(3, 0), at(5, 0)}, nil, at(3, 20))
+
+	testCases := []struct {
+		name           string
+		override       *WindowOverride
+		at             time.Time
+		expectedActive bool
+		expectedWindow Window
+	}{
+		{
+			name:           "scheduled",
+			at:             at(3, 30),
+			expectedActive: true,
+			expectedWindow: Window{at(3, 0), at(4, 0)},
+		},
+		{
+			name:           "started ahead of schedule",
+			override:       &startedAhead,
+			at:             at(2, 45),
+			expectedActive: true,
+			expectedWindow: Window{at(2, 30), at(3, 30)},
+		},
+		{
+			name:     "overlapped scheduled window is not reopened",
+			override: &startedAhead,
+			at:       at(3, 45),
+		},
+		{
+			name:     "ended early",
+			override: &endedEarly,
+			at:       at(3, 30),
+		},
+		{
+			name:           "next window after ended one",
+			override:       &endedEarly,
+			at:             at(3, 30).Add(24 * time.Hour),
+			expectedActive: true,
+			expectedWindow: Window{at(3, 0).Add(24 * time.Hour), at(4, 0).Add(24 * time.Hour)},
+		},
+		{
+			name:           "extended",
+			override:       &extended,
+			at:             at(4, 30),
+			expectedActive: true,
+			expectedWindow: Window{at(3, 0), at(5, 0)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, isActive := currentWindow(m, tc.override, tc.at)
+			assert.Equal(t, tc.expectedActive, isActive)
+			if tc.expectedActive {
+				assert.Equal(t, tc.expectedWindow, w)
+			}
+		})
+	}
+}
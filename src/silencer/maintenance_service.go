@@ -2,81 +2,242 @@ package silencer
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/robfig/cron/v3"
-	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 )
 
 type activeMaintenanceStorage interface {
-	Add(hash MaintenanceHash)
+	Add(hash MaintenanceHash, silenceID ActiveSilenceID)
 	Delete(hash MaintenanceHash)
+	Get(hash MaintenanceHash) (ActiveSilenceID, bool)
 	IsActive(hash MaintenanceHash) bool
 }
 
 type silencer interface {
 	Add(ctx context.Context, silence Silence) (ActiveSilenceID, error)
 	Delete(ctx context.Context, id ActiveSilenceID) error
+	SetEnd(ctx context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error)
 	ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error)
 }
 
+// silenceTimeTolerance is the precision silence times are compared with
+const silenceTimeTolerance = time.Second
+
+// Intervals between attempts to recover silences, when Alertmanager is unreachable on start
+const (
+	recoveryInitialInterval = 5 * time.Second
+	recoveryMaxInterval     = time.Minute
+)
+
+var (
+	ErrMaintenanceActive    = errors.New("maintenance is already active")
+	ErrMaintenanceNotActive = errors.New("maintenance is not active")
+	ErrMaintenanceFinished  = errors.New("maintenance is finished")
+	ErrEndNotInFuture       = errors.New("end of window must be in the future")
+)
+
 type MaintenanceService struct {
-	name                     string
-	maintenances             []Maintenance
-	activeMaintenanceStorage activeMaintenanceStorage
-	silencer                 silencer
-	clock                    clock
+	instance     Instance
+	maintenances []Maintenance
+	lookahead    time.Duration
+	// operationTimeout limits all Alertmanager calls of a single operation, since they are made under silencesMux
+	operationTimeout time.Duration
+	targets          []*targetState
+	store            StateStore
+	history          historyRecorder
+	clock            clock
 
 	cron        *cron.Cron
-	cronEntries map[int]cron.EntryID
+	cronEntries map[MaintenanceHash]cron.EntryID
+	started     bool
+	// recovered is set once silences are reconciled with every target after start
+	recovered bool
+	mux       sync.RWMutex
+
+	recoveryInterval time.Duration
+	stopRecovery     chan struct{}
+	recoveryDone     chan struct{}
+	// stopOnce makes Stop safe to call more than once
+	stopOnce sync.Once
+
+	// overrides and exceptions are changed with both silencesMux and mux locked, so either of them is enough to read them
+	overrides  map[MaintenanceHash]WindowOverride
+	exceptions map[MaintenanceHash][]OccurrenceException
+
+	// silencesMux serializes changes of silences, it is always locked before mux
+	silencesMux sync.Mutex
+
+	// lastErrorsMux guards lastErrors of targets
+	lastErrorsMux sync.RWMutex
 
 	logger logrus.FieldLogger
 }
 
+// targetState is state of maintenance silences in target
+type targetState struct {
+	Target
+	activeMaintenanceStorage activeMaintenanceStorage
+	activeWindows            map[MaintenanceHash]Window
+	expireTimers             map[MaintenanceHash]*time.Timer
+	pendingSilences          map[occurrence]ActiveSilenceID
+	lastErrors               map[MaintenanceHash]error
+	logger                   logrus.FieldLogger
+}
+
+// NewMaintenanceService creates service, which silences maintenances in every target. Silences of windows starting
+// within lookahead are created ahead of time as pending silences, zero lookahead disables it.
+// Alertmanager calls of every operation, e.g. reconciliation or reload, share operationTimeout, zero disables it.
+// State of silences and window overrides is kept in store, so restart resumes where it left off.
+// Silence calls are recorded to history.
 func NewMaintenanceService(
-	name string,
+	instance Instance,
 	maintenances []Maintenance,
-	activeMaintenanceStorage activeMaintenanceStorage,
-	silencer silencer,
+	lookahead time.Duration,
+	operationTimeout time.Duration,
+	targets []Target,
+	store StateStore,
+	history historyRecorder,
 	clock clock,
 	logger logrus.FieldLogger,
 ) *MaintenanceService {
+	states := make([]*targetState, len(targets))
+	for i, target := range targets {
+		states[i] = &targetState{
+			Target:                   target,
+			activeMaintenanceStorage: NewActiveMaintenanceStorage(),
+			activeWindows:            make(map[MaintenanceHash]Window),
+			expireTimers:             make(map[MaintenanceHash]*time.Timer),
+			pendingSilences:          make(map[occurrence]ActiveSilenceID),
+			lastErrors:               make(map[MaintenanceHash]error),
+			logger:                   logger.WithField("target", target.Name()),
+		}
+	}
+
 	return &MaintenanceService{
-		name,
-		maintenances,
-		activeMaintenanceStorage,
-		silencer,
-		clock,
-		cron.New(),
-		make(map[int]cron.EntryID),
-		logger,
+		instance:     instance,
+		maintenances: maintenances,
+		lookahead:    lookahead,
+
+		operationTimeout: operationTimeout,
+		targets:          states,
+		store:            store,
+		history:          history,
+		clock:            clock,
+		cron:             cron.New(),
+		cronEntries:      make(map[MaintenanceHash]cron.EntryID),
+		overrides:        make(map[MaintenanceHash]WindowOverride),
+		exceptions:       make(map[MaintenanceHash][]OccurrenceException),
+
+		recoveryInterval: recoveryInitialInterval,
+		stopRecovery:     make(chan struct{}),
+		recoveryDone:     make(chan struct{}),
+
+		logger: logger,
 	}
 }
 
+// Start restores state and schedules maintenances. Silences are reconciled with targets right away,
+// when some target is unreachable, reconciliation is retried in background until it succeeds.
+// Only failure to restore state is returned.
 func (s *MaintenanceService) Start() error {
-	ctx := context.Background()
+	s.silencesMux.Lock()
+	defer s.silencesMux.Unlock()
+	defer s.saveState()
+
+	ctx, cancel := s.operationContext(HistoryActionReconcile, "")
+	defer cancel()
 
-	err := s.recoverState(ctx)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	err := s.restoreState()
 	if err != nil {
-		return err
+		return errors.Wrap(err, "failed to restore state")
 	}
 
-	for i, maintenance := range s.maintenances {
-		entryID := s.cron.Schedule(maintenance.Schedule, cron.FuncJob(func() {
-			s.addMaintenance(ctx, maintenance, s.clock.Now())
-		}))
+	s.checkRoutes(s.maintenances)
+	var recoveryErr error
+	for _, t := range s.targets {
+		err := s.reconcile(ctx, t, s.maintenances)
+		if err != nil && recoveryErr == nil {
+			recoveryErr = errors.Wrapf(err, "failed to reconcile target %s", t.Name())
+		}
+	}
 
-		s.cronEntries[i] = entryID
+	for _, maintenance := range s.maintenances {
+		s.schedule(maintenance)
 	}
 
 	s.cron.Start()
+	s.started = true
+
+	if recoveryErr != nil {
+		s.logger.WithError(recoveryErr).Errorf("failed to recover silences, retrying in %s", s.recoveryInterval)
+		go s.retryRecovery()
+		return nil
+	}
+
+	s.recovered = true
+	close(s.recoveryDone)
 
 	return nil
 }
 
+// retryRecovery reconciles silences with backoff until it succeeds or service is stopped.
+func (s *MaintenanceService) retryRecovery() {
+	defer close(s.recoveryDone)
+
+	interval := s.recoveryInterval
+	for {
+		select {
+		case <-s.stopRecovery:
+			return
+		case <-time.After(interval):
+		}
+
+		err := s.Reconcile()
+		if err == nil {
+			break
+		}
+
+		interval *= 2
+		if interval > recoveryMaxInterval {
+			interval = recoveryMaxInterval
+		}
+		s.logger.WithError(err).Errorf("failed to recover silences, retrying in %s", interval)
+	}
+
+	s.mux.Lock()
+	s.recovered = true
+	s.mux.Unlock()
+
+	s.logger.Info("silences recovered")
+}
+
 func (s *MaintenanceService) Stop(ctx context.Context) error {
+	s.mux.RLock()
+	started := s.started
+	s.mux.RUnlock()
+
+	s.stopOnce.Do(func() {
+		close(s.stopRecovery)
+	})
+	if started {
+		select {
+		case <-s.recoveryDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
 	stopCtx := s.cron.Stop()
 	<-stopCtx.Done()
 	err := stopCtx.Err()
@@ -87,119 +248,872 @@ func (s *MaintenanceService) Stop(ctx context.Context) error {
 	return nil
 }
 
+// operationContext is context of Alertmanager calls made for action of actor under silencesMux.
+// Calls share the deadline, so unreachable Alertmanager holds up other operations for operationTimeout at most.
+// Calls failed on deadline are repeated by reconciliation.
+func (s *MaintenanceService) operationContext(action string, actor string) (context.Context, context.CancelFunc) {
+	ctx := withHistoryCause(context.Background(), action, actor)
+	if s.operationTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, s.operationTimeout)
+}
+
+// Ready tells why service is not ready yet: scheduler is not running or silences are not recovered after start.
+func (s *MaintenanceService) Ready() error {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if !s.started {
+		return errors.New("scheduler is not running")
+	}
+
+	if !s.recovered {
+		return errors.New("silences are not recovered yet")
+	}
+
+	return nil
+}
+
+// Reload replaces watched maintenances. Maintenances are matched by hash:
+// dropped ones are unscheduled and their silences are expired, new and changed ones are (re)scheduled
+// and started right away when their window is already open.
+func (s *MaintenanceService) Reload(maintenances []Maintenance) {
+	s.silencesMux.Lock()
+	defer s.silencesMux.Unlock()
+	defer s.saveState()
+
+	ctx, cancel := s.operationContext(HistoryActionReload, "")
+	defer cancel()
+
+	s.mux.Lock()
+	maintenances = s.withExceptions(maintenances)
+	if !s.started {
+		s.maintenances = maintenances
+		s.mux.Unlock()
+		return
+	}
+
+	s.checkRoutes(maintenances)
+
+	actual := buildMaintenanceIndex(maintenances)
+	for _, m := range s.maintenances {
+		a, ok := actual[m.Hash]
+		if ok && a.ContentHash == m.ContentHash {
+			delete(actual, m.Hash)
+			continue
+		}
+
+		s.unschedule(m.Hash)
+
+		if !ok {
+			delete(s.overrides, m.Hash)
+		}
+
+		// maintenance is dropped or moved to other targets
+		for _, t := range s.targets {
+			if !ok || !t.Routes(a) {
+				t.deactivate(m.Hash)
+			}
+		}
+	}
+
+	for _, m := range maintenances {
+		if _, ok := actual[m.Hash]; ok {
+			s.schedule(m)
+		}
+	}
+
+	s.maintenances = maintenances
+	s.mux.Unlock()
+
+	for _, t := range s.targets {
+		err := s.reconcile(ctx, t, maintenances)
+		if err != nil {
+			t.logger.WithError(err).Error("failed to reconcile silences after reload")
+		}
+	}
+}
+
+// Reconcile compares silences, which should exist according to maintenances and clock, with every target.
+// Missing silences are recreated, stray ones are expired and active maintenances are corrected.
+// Failure of one target does not prevent reconciliation of others.
+func (s *MaintenanceService) Reconcile() error {
+	s.silencesMux.Lock()
+	defer s.silencesMux.Unlock()
+	defer s.saveState()
+
+	s.mux.Lock()
+	s.dropFinishedOverrides(s.clock.Now())
+	maintenances := s.maintenances
+	s.mux.Unlock()
+
+	ctx, cancel := s.operationContext(HistoryActionReconcile, "")
+	defer cancel()
+
+	var result error
+	for _, t := range s.targets {
+		err := s.reconcile(ctx, t, maintenances)
+		if err != nil && result == nil {
+			result = errors.Wrapf(err, "failed to reconcile target %s", t.Name())
+		}
+	}
+
+	return result
+}
+
 type WatchedMaintenance struct {
 	Maintenance Maintenance
 	Next        time.Time
-	IsActive    bool
+	// IsActive is true, when maintenance is silenced in any target
+	IsActive   bool
+	IsFinished bool
+	// Window is the window open now, it is zero when there is none
+	Window Window
+	// Override is manual change of window, which is in effect now
+	Override *WindowOverride
+	// Exceptions are skipped and postponed occurrences, which are not over yet
+	Exceptions []OccurrenceException
+	// LastError is the last failure of Alertmanager call made for maintenance in any target
+	LastError error
+	Targets   []WatchedTarget
+}
+
+// WatchedTarget is state of maintenance in target
+type WatchedTarget struct {
+	Name      string
+	IsActive  bool
+	SilenceID ActiveSilenceID
+	LastError error
 }
 
 func (s *MaintenanceService) WatchedMaintenances() []WatchedMaintenance {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
 	result := make([]WatchedMaintenance, len(s.maintenances))
 
 	now := s.clock.Now()
 	for i, m := range s.maintenances {
-		result[i] = WatchedMaintenance{
+		watched := WatchedMaintenance{
 			Maintenance: m,
-			IsActive:    s.activeMaintenanceStorage.IsActive(m.Hash),
-			Next:        s.cron.Entry(s.cronEntries[i]).Schedule.Next(now),
+			Next:        m.Schedule.Next(now),
+			IsFinished:  m.FinishedAt(now),
+			Targets:     make([]WatchedTarget, 0, len(s.targets)),
+		}
+
+		for _, e := range s.exceptions[m.Hash] {
+			if !e.IsOverAt(now, m.Duration) {
+				watched.Exceptions = append(watched.Exceptions, e)
+			}
+		}
+
+		if override := s.override(m.Hash); override.LastsAt(now) {
+			watched.Override = override
+		}
+
+		if w, isActive := currentWindow(m, watched.Override, now); isActive {
+			watched.Window = w
 		}
+
+		for _, t := range s.targets {
+			if !t.Routes(m) {
+				continue
+			}
+
+			silenceID, isActive := t.activeMaintenanceStorage.Get(m.Hash)
+			lastError := s.lastError(t, m.Hash)
+
+			watched.IsActive = watched.IsActive || isActive
+			if watched.LastError == nil {
+				watched.LastError = lastError
+			}
+
+			watched.Targets = append(watched.Targets, WatchedTarget{
+				Name:      t.Name(),
+				IsActive:  isActive,
+				SilenceID: silenceID,
+				LastError: lastError,
+			})
+		}
+
+		result[i] = watched
 	}
 
 	return result
 }
 
-func (s *MaintenanceService) addMaintenance(ctx context.Context, maintenance Maintenance, startAt time.Time) {
-	silenceID, err := s.silencer.Add(ctx, Silence{
-		maintenance.Matchers,
-		startAt,
-		maintenance.Duration,
-		maintenance.Hash.String(),
-		s.name,
-	})
+// StartNow opens window of maintenance right away, ahead of its schedule. Window lasts for maintenance duration,
+// scheduled windows it overlaps are not opened.
+func (s *MaintenanceService) StartNow(hash MaintenanceHash, actor string) error {
+	return s.overrideWindow(hash, OverrideActionStart, actor, startNow)
+}
+
+// EndNow closes the current window of maintenance early, its silences are expired.
+func (s *MaintenanceService) EndNow(hash MaintenanceHash, actor string) error {
+	return s.overrideWindow(hash, OverrideActionEnd, actor, endNow)
+}
+
+// Extend moves end of the current window of maintenance to endAt, its silences are updated in place.
+func (s *MaintenanceService) Extend(hash MaintenanceHash, endAt time.Time, actor string) error {
+	return s.overrideWindow(hash, OverrideActionExtend, actor, extendTo(endAt))
+}
+
+// overrideWindow replaces the current window of maintenance with the one chosen by action, and applies it
+// to silences of every target maintenance is routed to. Failed Alertmanager calls are fixed by reconciliation.
+func (s *MaintenanceService) overrideWindow(
+	hash MaintenanceHash,
+	action string,
+	actor string,
+	window windowChange,
+) error {
+	s.silencesMux.Lock()
+	defer s.silencesMux.Unlock()
+	defer s.saveState()
+
+	ctx, cancel := s.operationContext(action, actor)
+	defer cancel()
+
+	m, override, err := s.setOverride(hash, action, actor, window)
 	if err != nil {
-		s.logger.WithError(err).Infof("failed to post silence: %s", err.Error())
-		return
+		return err
 	}
 
-	s.activeMaintenanceStorage.Add(maintenance.Hash)
+	s.logger.Infof(
+		"%s of maintenance %s by %s, window is %s - %s",
+		action,
+		hash,
+		actor,
+		override.Window.StartAt.Format(time.RFC3339),
+		override.Window.EndAt.Format(time.RFC3339),
+	)
+	s.history.Record(HistoryEntry{
+		Event:       HistoryEventManual,
+		Action:      action,
+		Maintenance: hash,
+		Window:      &APIWindow{override.Window.StartAt, override.Window.EndAt},
+		Actor:       actor,
+	})
 
-	time.AfterFunc(maintenance.Duration, func() {
-		err := s.silencer.Delete(ctx, silenceID)
-		if err != nil {
-			s.logger.WithError(err).Infof("failed to delete silence %s", silenceID)
+	for _, t := range s.targets {
+		if !t.Routes(m) {
+			continue
 		}
 
-		s.activeMaintenanceStorage.Delete(maintenance.Hash)
-	})
+		s.applyWindow(ctx, t, m, override.Window, override.At)
+		s.deleteOverriddenPendingSilences(ctx, t, m, &override)
+	}
+
+	return nil
 }
 
-func (s *MaintenanceService) recoverState(ctx context.Context) error {
-	activeSilences, err := s.silencer.ActiveSilences(ctx, s.name)
+func (s *MaintenanceService) setOverride(
+	hash MaintenanceHash,
+	action string,
+	actor string,
+	window windowChange,
+) (Maintenance, WindowOverride, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	m, ok := s.maintenance(hash)
+	if !ok {
+		return Maintenance{}, WindowOverride{}, ErrMaintenanceNotFound
+	}
+
+	now := s.clock.Now()
+	previous := s.override(hash)
+	if !previous.LastsAt(now) {
+		previous = nil
+	}
+
+	current, isActive := currentWindow(m, previous, now)
+	w, err := window(m, current, isActive, now)
 	if err != nil {
-		return err
+		return Maintenance{}, WindowOverride{}, err
+	}
+
+	override := newWindowOverride(m, action, actor, w, previous, now)
+	s.overrides[hash] = override
+
+	return m, override, nil
+}
+
+// applyWindow makes silence of maintenance in target match window: silence is posted, updated or expired.
+func (s *MaintenanceService) applyWindow(ctx context.Context, t *targetState, m Maintenance, w Window, now time.Time) {
+	silenceID, isActive := t.activeMaintenanceStorage.Get(m.Hash)
+	if !w.Contains(now) {
+		if !isActive {
+			return
+		}
+
+		t.deactivate(m.Hash)
+
+		err := t.silencer.Delete(ctx, silenceID)
+		s.recordSilence(ctx, t, HistoryEventSilenceDeleted, m.Hash, silenceID, w, err)
+		s.setLastError(t, m.Hash, err)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to delete silence %s", silenceID)
+		}
+		return
 	}
 
-	activeMaintenanceIndex, err := buildActiveMaintenanceIndex(activeSilences)
+	if !isActive {
+		s.addMaintenance(ctx, t, m, w)
+		return
+	}
+
+	updatedID, err := t.silencer.SetEnd(ctx, silenceID, w.EndAt)
+	s.setLastError(t, m.Hash, err)
 	if err != nil {
-		return err
+		s.recordSilence(ctx, t, HistoryEventSilenceUpdated, m.Hash, silenceID, w, err)
+		t.logger.WithError(err).Errorf("failed to update silence %s", silenceID)
+		return
 	}
 
-	maintenancesWithoutSilences := make([]Maintenance, 0)
+	s.recordSilence(ctx, t, HistoryEventSilenceUpdated, m.Hash, updatedID, w, nil)
+	s.watchSilence(t, m, w, updatedID)
+}
+
+// deleteOverriddenPendingSilences expires pending silences of scheduled windows, which are replaced by override.
+func (s *MaintenanceService) deleteOverriddenPendingSilences(
+	ctx context.Context,
+	t *targetState,
+	m Maintenance,
+	override *WindowOverride,
+) {
+	for key, silenceID := range t.pendingSilences {
+		if key.hash != m.Hash || !override.LastsAt(time.Unix(key.startAt, 0)) {
+			continue
+		}
+
+		delete(t.pendingSilences, key)
+
+		err := t.silencer.Delete(ctx, silenceID)
+		s.recordSilence(ctx, t, HistoryEventSilenceDeleted, m.Hash, silenceID, Window{}, err)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to delete pending silence %s", silenceID)
+		}
+	}
+}
+
+func (s *MaintenanceService) maintenance(hash MaintenanceHash) (Maintenance, bool) {
 	for _, m := range s.maintenances {
-		_, ok := activeMaintenanceIndex[m.Hash]
-		if ok {
-			s.activeMaintenanceStorage.Add(m.Hash)
-			delete(activeMaintenanceIndex, m.Hash)
-		} else {
-			maintenancesWithoutSilences = append(maintenancesWithoutSilences, m)
+		if m.Hash == hash {
+			return m, true
 		}
 	}
 
-	nonActualActiveSilenceIndex := buildActiveSilenceIndex(activeMaintenanceIndex)
+	return Maintenance{}, false
+}
 
-	for _, silence := range activeSilences {
-		_, ok := nonActualActiveSilenceIndex[silence.ID]
-		if ok {
-			err := s.silencer.Delete(ctx, silence.ID)
-			if err != nil {
-				return err
-			}
+// override returns override of maintenance window, nil when there is none.
+func (s *MaintenanceService) override(hash MaintenanceHash) *WindowOverride {
+	override, ok := s.overrides[hash]
+	if !ok {
+		return nil
+	}
+
+	return &override
+}
+
+func (s *MaintenanceService) dropFinishedOverrides(now time.Time) {
+	for hash, override := range s.overrides {
+		if !override.LastsAt(now) {
+			delete(s.overrides, hash)
 		}
 	}
+}
+
+// SetOccurrenceExceptions replaces skipped and postponed occurrences of maintenances, which are rescheduled accordingly.
+// Exceptions of unknown maintenances are kept, they apply once maintenance is added.
+func (s *MaintenanceService) SetOccurrenceExceptions(exceptions []OccurrenceException) {
+	s.silencesMux.Lock()
+	defer s.silencesMux.Unlock()
+	defer s.saveState()
+
+	ctx, cancel := s.operationContext(HistoryActionReconcile, "")
+	defer cancel()
+
+	s.mux.Lock()
+	s.exceptions = make(map[MaintenanceHash][]OccurrenceException)
+	for _, e := range exceptions {
+		s.exceptions[e.Maintenance] = append(s.exceptions[e.Maintenance], e)
+	}
 
-	s.addMissingActiveMaintenances(ctx, maintenancesWithoutSilences)
+	maintenances := s.withExceptions(s.maintenances)
+	s.maintenances = maintenances
+	if !s.started {
+		s.mux.Unlock()
+		return
+	}
+
+	for _, m := range maintenances {
+		s.unschedule(m.Hash)
+		s.schedule(m)
+	}
+	s.mux.Unlock()
+
+	for _, t := range s.targets {
+		err := s.reconcile(ctx, t, maintenances)
+		if err != nil {
+			t.logger.WithError(err).Error("failed to reconcile silences after exceptions change")
+		}
+	}
+}
+
+// CheckOccurrence makes sure maintenance has window scheduled at scheduledAt, exceptions aside.
+func (s *MaintenanceService) CheckOccurrence(hash MaintenanceHash, scheduledAt time.Time) error {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	m, ok := s.maintenance(hash)
+	if !ok {
+		return ErrMaintenanceNotFound
+	}
+
+	if !baseSchedule(m.Schedule).Next(scheduledAt.Add(-time.Second)).Equal(scheduledAt) {
+		return ErrOccurrenceNotFound
+	}
 
 	return nil
 }
 
-func (s *MaintenanceService) addMissingActiveMaintenances(ctx context.Context, maintenances []Maintenance) {
+// IsExceptionOver tells whether windows affected by exception are over at t. Exception of unknown maintenance
+// is over once occurrences it refers to have started.
+func (s *MaintenanceService) IsExceptionOver(e OccurrenceException, t time.Time) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	var duration time.Duration
+	if m, ok := s.maintenance(e.Maintenance); ok {
+		duration = m.Duration
+	}
+
+	return e.IsOverAt(t, duration)
+}
+
+func (s *MaintenanceService) withExceptions(maintenances []Maintenance) []Maintenance {
+	result := make([]Maintenance, len(maintenances))
+	for i, m := range maintenances {
+		result[i] = withExceptions(m, s.exceptions[m.Hash])
+	}
+
+	return result
+}
+
+func (s *MaintenanceService) unschedule(hash MaintenanceHash) {
+	if entryID, ok := s.cronEntries[hash]; ok {
+		s.cron.Remove(entryID)
+		delete(s.cronEntries, hash)
+	}
+}
+
+// schedule adds cron entry for maintenance. Finished one-off maintenances are not scheduled.
+func (s *MaintenanceService) schedule(maintenance Maintenance) {
+	if maintenance.FinishedAt(s.clock.Now()) {
+		return
+	}
+
+	s.cronEntries[maintenance.Hash] = s.cron.Schedule(maintenance.Schedule, cron.FuncJob(func() {
+		s.silencesMux.Lock()
+		defer s.silencesMux.Unlock()
+		defer s.saveState()
+
+		ctx, cancel := s.operationContext(HistoryActionSchedule, "")
+		defer cancel()
+
+		now := s.clock.Now()
+		startAt := now
+		if isActive, scheduledAt := maintenance.ActiveAt(now); isActive {
+			startAt = scheduledAt
+		}
+
+		if s.override(maintenance.Hash).LastsAt(startAt) {
+			s.logger.Infof("window of maintenance %s is overridden, not starting it", maintenance.Hash)
+			return
+		}
+
+		for _, t := range s.targets {
+			if !t.Routes(maintenance) {
+				continue
+			}
+
+			s.startMaintenance(ctx, t, maintenance, Window{startAt, startAt.Add(maintenance.Duration)})
+			s.addPendingSilences(ctx, t, maintenance, now)
+		}
+	}))
+}
+
+// startMaintenance activates pending silence of the window, or posts a new one.
+func (s *MaintenanceService) startMaintenance(ctx context.Context, t *targetState, maintenance Maintenance, w Window) {
+	key := newOccurrence(maintenance.Hash, w.StartAt)
+	if silenceID, ok := t.pendingSilences[key]; ok {
+		delete(t.pendingSilences, key)
+		s.watchSilence(t, maintenance, w, silenceID)
+		return
+	}
+
+	s.addMaintenance(ctx, t, maintenance, w)
+}
+
+func (s *MaintenanceService) addMaintenance(ctx context.Context, t *targetState, maintenance Maintenance, w Window) {
+	silenceID, err := s.postSilence(ctx, t, maintenance, w)
+	s.setLastError(t, maintenance.Hash, err)
+	if err != nil {
+		t.logger.WithError(err).Errorf("failed to post silence of maintenance %s", maintenance.Hash)
+		return
+	}
+
+	s.watchSilence(t, maintenance, w, silenceID)
+}
+
+// addPendingSilences posts silences of maintenance windows starting within lookahead.
+func (s *MaintenanceService) addPendingSilences(ctx context.Context, t *targetState, maintenance Maintenance, now time.Time) {
+	if s.lookahead <= 0 {
+		return
+	}
+
+	override := s.override(maintenance.Hash)
+	for _, w := range maintenance.Windows(now, now.Add(s.lookahead)) {
+		key := newOccurrence(maintenance.Hash, w.StartAt)
+		if _, ok := t.pendingSilences[key]; ok || override.LastsAt(w.StartAt) {
+			continue
+		}
+
+		silenceID, err := s.postSilence(ctx, t, maintenance, w)
+		s.setLastError(t, maintenance.Hash, err)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to post pending silence of maintenance %s", maintenance.Hash)
+			return
+		}
+
+		t.pendingSilences[key] = silenceID
+	}
+}
+
+func (s *MaintenanceService) postSilence(
+	ctx context.Context,
+	t *targetState,
+	maintenance Maintenance,
+	w Window,
+) (ActiveSilenceID, error) {
+	silenceID, err := t.silencer.Add(ctx, Silence{
+		maintenance.Matchers,
+		w.StartAt,
+		w.EndAt.Sub(w.StartAt),
+		s.instance.Comment(maintenance.Hash),
+		s.instance.CreatedBy(),
+	})
+	s.recordSilence(ctx, t, HistoryEventSilenceCreated, maintenance.Hash, silenceID, w, err)
+
+	return silenceID, err
+}
+
+// watchSilence marks maintenance active and deletes its silence when window is over.
+func (s *MaintenanceService) watchSilence(t *targetState, maintenance Maintenance, w Window, silenceID ActiveSilenceID) {
+	t.activeMaintenanceStorage.Add(maintenance.Hash, silenceID)
+	t.activeWindows[maintenance.Hash] = w
+
+	t.stopExpireTimer(maintenance.Hash)
+	t.expireTimers[maintenance.Hash] = time.AfterFunc(w.EndAt.Sub(s.clock.Now()), func() {
+		s.silencesMux.Lock()
+		defer s.silencesMux.Unlock()
+		defer s.saveState()
+
+		id, ok := t.activeMaintenanceStorage.Get(maintenance.Hash)
+		if !ok || id != silenceID {
+			return
+		}
+
+		ctx, cancel := s.operationContext(HistoryActionExpire, "")
+		defer cancel()
+
+		err := t.silencer.Delete(ctx, silenceID)
+		s.recordSilence(ctx, t, HistoryEventSilenceDeleted, maintenance.Hash, silenceID, w, err)
+		s.setLastError(t, maintenance.Hash, err)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to delete silence %s", silenceID)
+		}
+
+		delete(t.expireTimers, maintenance.Hash)
+		t.deactivate(maintenance.Hash)
+	})
+}
+
+func (t *targetState) stopExpireTimer(hash MaintenanceHash) {
+	if timer, ok := t.expireTimers[hash]; ok {
+		timer.Stop()
+		delete(t.expireTimers, hash)
+	}
+}
+
+// deactivate forgets silence of maintenance window.
+func (t *targetState) deactivate(hash MaintenanceHash) {
+	t.stopExpireTimer(hash)
+	t.activeMaintenanceStorage.Delete(hash)
+	delete(t.activeWindows, hash)
+}
+
+// reconcile matches silences of instance in target with windows of maintenances routed to it: the current window of every
+// active maintenance and windows starting within lookahead. Silence matches window, when it belongs to
+// the same maintenance, has the same matchers and ends with the window. Unmatched silences are stray.
+func (s *MaintenanceService) reconcile(ctx context.Context, t *targetState, maintenances []Maintenance) error {
+	activeSilences, err := t.silencer.ActiveSilences(ctx, s.instance.CreatedBy())
+	if err != nil {
+		return err
+	}
+
+	silenceIndex := buildMaintenanceSilenceIndex(s.instance, activeSilences)
+	pendingSilences := make(map[occurrence]ActiveSilenceID)
+
 	now := s.clock.Now()
 	for _, m := range maintenances {
-		isActive, startAt := m.ActiveAt(now)
+		if !t.Routes(m) {
+			continue
+		}
+
+		silences := silenceIndex[m.Hash]
+		override := s.override(m.Hash)
+
+		w, isActive := currentWindow(m, override, now)
 		if isActive {
-			s.addMaintenance(ctx, m, startAt)
+			silences = s.reconcileActiveWindow(ctx, t, m, w, silences, silenceIndex)
+		} else if t.activeMaintenanceStorage.IsActive(m.Hash) {
+			t.logger.Warnf("maintenance %s is not active anymore", m.Hash)
+			t.deactivate(m.Hash)
 		}
+
+		if s.lookahead > 0 {
+			for _, w := range m.Windows(now, now.Add(s.lookahead)) {
+				if override.LastsAt(w.StartAt) {
+					continue
+				}
+
+				var silence ActiveSilence
+				silence, silences = takeMatchingSilence(silences, m, w)
+				if silence.ID != "" {
+					pendingSilences[newOccurrence(m.Hash, w.StartAt)] = silence.ID
+					continue
+				}
+
+				silenceID, err := s.postSilence(ctx, t, m, w)
+				s.setLastError(t, m.Hash, err)
+				if err != nil {
+					t.logger.WithError(err).Errorf("failed to post pending silence of maintenance %s", m.Hash)
+					continue
+				}
+				pendingSilences[newOccurrence(m.Hash, w.StartAt)] = silenceID
+			}
+		}
+
+		silenceIndex[m.Hash] = silences
 	}
+
+	t.pendingSilences = pendingSilences
+
+	for hash, silences := range silenceIndex {
+		s.deleteStraySilences(ctx, t, hash, silences)
+	}
+
+	return nil
 }
 
-func buildActiveMaintenanceIndex(activeSilences []ActiveSilence) (map[MaintenanceHash]ActiveSilenceID, error) {
-	result := make(map[MaintenanceHash]ActiveSilenceID)
-	for _, s := range activeSilences {
-		hash, err := uuid.FromString(s.Comment)
+// reconcileActiveWindow makes sure the current window of maintenance is silenced, it returns silences left unmatched.
+func (s *MaintenanceService) reconcileActiveWindow(
+	ctx context.Context,
+	t *targetState,
+	maintenance Maintenance,
+	w Window,
+	silences []ActiveSilence,
+	silenceIndex map[MaintenanceHash][]ActiveSilence,
+) []ActiveSilence {
+	silence, silences := takeMatchingSilence(silences, maintenance, w)
+	if silence.ID != "" {
+		if current, _ := t.activeMaintenanceStorage.Get(maintenance.Hash); current != silence.ID {
+			s.watchSilence(t, maintenance, w, silence.ID)
+		}
+		return silences
+	}
+
+	if maintenance.ContentHash != maintenance.Hash {
+		legacySilences := silenceIndex[maintenance.ContentHash]
+		if len(legacySilences) > 0 {
+			s.migrateSilence(ctx, t, maintenance, w, legacySilences[0].ID)
+			silenceIndex[maintenance.ContentHash] = legacySilences[1:]
+			return silences
+		}
+	}
+
+	if s.started {
+		t.logger.Warnf("silence of active maintenance %s is missing, recreating", maintenance.Hash)
+	}
+	s.addMaintenance(ctx, t, maintenance, w)
+
+	return silences
+}
+
+func (s *MaintenanceService) deleteStraySilences(
+	ctx context.Context,
+	t *targetState,
+	hash MaintenanceHash,
+	silences []ActiveSilence,
+) {
+	for _, silence := range silences {
+		err := t.silencer.Delete(ctx, silence.ID)
+		s.recordSilence(ctx, t, HistoryEventSilenceDeleted, hash, silence.ID, Window{silence.StartsAt, silence.EndsAt}, err)
 		if err != nil {
-			return nil, err
+			t.logger.WithError(err).Errorf("failed to delete stray silence %s", silence.ID)
+			continue
 		}
 
-		result[MaintenanceHash(hash)] = s.ID
+		if s.started {
+			t.logger.Warnf("stray silence %s deleted", silence.ID)
+		}
+	}
+}
+
+// migrateSilence rebinds silence, created when maintenance was identified by content hash, to maintenance identity.
+// New silence is posted before legacy one is deleted, so alerts stay silenced.
+func (s *MaintenanceService) migrateSilence(
+	ctx context.Context,
+	t *targetState,
+	maintenance Maintenance,
+	w Window,
+	legacySilenceID ActiveSilenceID,
+) {
+	silenceID, err := s.postSilence(ctx, t, maintenance, w)
+	s.setLastError(t, maintenance.Hash, err)
+	if err != nil {
+		t.logger.WithError(err).Errorf("failed to migrate silence %s of maintenance %s", legacySilenceID, maintenance.ID)
+		s.watchSilence(t, maintenance, w, legacySilenceID)
+		return
 	}
 
-	return result, nil
+	s.watchSilence(t, maintenance, w, silenceID)
+
+	err = t.silencer.Delete(ctx, legacySilenceID)
+	s.recordSilence(ctx, t, HistoryEventSilenceDeleted, maintenance.ContentHash, legacySilenceID, w, err)
+	if err != nil {
+		t.logger.WithError(err).Errorf("failed to delete migrated silence %s", legacySilenceID)
+	}
+}
+
+// IsRouted tells whether maintenance is silenced in any target.
+func (s *MaintenanceService) IsRouted(m Maintenance) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return s.isRouted(m)
 }
 
-func buildActiveSilenceIndex(activeMaintenanceIndex map[MaintenanceHash]ActiveSilenceID) map[ActiveSilenceID]struct{} {
-	result := make(map[ActiveSilenceID]struct{})
-	for _, v := range activeMaintenanceIndex {
-		result[v] = struct{}{}
+func (s *MaintenanceService) isRouted(m Maintenance) bool {
+	for _, t := range s.targets {
+		if t.Routes(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRoutes reports maintenances, which are not silenced anywhere: targets are created on start,
+// so alertmanagers added to config later are not known.
+func (s *MaintenanceService) checkRoutes(maintenances []Maintenance) {
+	for _, m := range maintenances {
+		if !s.isRouted(m) {
+			s.logger.Errorf("alertmanager %s of maintenance %s is unknown, restart to apply alertmanagers", m.Alertmanager, m.Hash)
+		}
+	}
+}
+
+// setLastError remembers failure of maintenance in target, nil error clears it.
+func (s *MaintenanceService) setLastError(t *targetState, hash MaintenanceHash, err error) {
+	s.lastErrorsMux.Lock()
+	defer s.lastErrorsMux.Unlock()
+
+	if err == nil {
+		delete(t.lastErrors, hash)
+		return
+	}
+
+	t.lastErrors[hash] = err
+}
+
+func (s *MaintenanceService) lastError(t *targetState, hash MaintenanceHash) error {
+	s.lastErrorsMux.RLock()
+	defer s.lastErrorsMux.RUnlock()
+
+	return t.lastErrors[hash]
+}
+
+// occurrence identifies single window of maintenance
+type occurrence struct {
+	hash    MaintenanceHash
+	startAt int64
+}
+
+func newOccurrence(hash MaintenanceHash, startAt time.Time) occurrence {
+	return occurrence{hash, startAt.Unix()}
+}
+
+// takeMatchingSilence finds silence of window and returns it along with the rest of silences.
+// Zero silence is returned when there is no match.
+func takeMatchingSilence(silences []ActiveSilence, maintenance Maintenance, w Window) (ActiveSilence, []ActiveSilence) {
+	for i, silence := range silences {
+		if !closeTimes(silence.EndsAt, w.EndAt) || matchersKey(silence.Matchers) != matchersKey(maintenance.Matchers) {
+			continue
+		}
+
+		rest := make([]ActiveSilence, 0, len(silences)-1)
+		rest = append(rest, silences[:i]...)
+		rest = append(rest, silences[i+1:]...)
+
+		return silence, rest
+	}
+
+	return ActiveSilence{}, silences
+}
+
+func closeTimes(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d < silenceTimeTolerance && d > -silenceTimeTolerance
+}
+
+// matchersKey is order independent representation of matchers.
+func matchersKey(matchers models.Matchers) string {
+	keys := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		if m == nil || m.Name == nil || m.Value == nil || m.IsRegex == nil {
+			continue
+		}
+		keys = append(keys, *m.Name+"\x00"+*m.Value+"\x00"+strconv.FormatBool(*m.IsRegex))
+	}
+	sort.Strings(keys)
+
+	return strings.Join(keys, "\x01")
+}
+
+func buildMaintenanceIndex(maintenances []Maintenance) map[MaintenanceHash]Maintenance {
+	result := make(map[MaintenanceHash]Maintenance, len(maintenances))
+	for _, m := range maintenances {
+		result[m.Hash] = m
+	}
+
+	return result
+}
+
+// buildMaintenanceSilenceIndex groups silences by maintenances. Silences not owned by instance are skipped.
+func buildMaintenanceSilenceIndex(instance Instance, activeSilences []ActiveSilence) map[MaintenanceHash][]ActiveSilence {
+	result := make(map[MaintenanceHash][]ActiveSilence)
+	for _, s := range activeSilences {
+		hash, ok := instance.ParseComment(s.Comment)
+		if !ok {
+			continue
+		}
+
+		result[hash] = append(result[hash], s)
 	}
 
 	return result
@@ -0,0 +1,750 @@
+package silencer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceService_Reload(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	kept := YamlMaintenance{
+		Matchers: []string{"alertname=kept"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	dropped := YamlMaintenance{
+		Matchers: []string{"alertname=dropped"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	added := YamlMaintenance{
+		Matchers: []string{"alertname=added"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	silencer := newSilencerMock()
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{kept, dropped})),
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, silencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	assert.ElementsMatch(t, []string{kept.Hash().String(), dropped.Hash().String()}, silencer.comments())
+
+	maintenanceService.Reload(MustMaintenances(ParseMaintenances([]YamlMaintenance{kept, added})))
+
+	assert.ElementsMatch(t, []string{kept.Hash().String(), added.Hash().String()}, silencer.comments())
+
+	watched := make(map[MaintenanceHash]bool)
+	for _, m := range maintenanceService.WatchedMaintenances() {
+		watched[m.Maintenance.Hash] = m.IsActive
+	}
+	assert.Equal(t, map[MaintenanceHash]bool{
+		kept.Hash():  true,
+		added.Hash(): true,
+	}, watched)
+}
+
+func TestMaintenanceService_MigratesContentHashSilences(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	legacy := YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	withID := legacy
+	withID.ID = "test"
+
+	m := MustMaintenance(ParseMaintenance(withID))
+
+	silencer := newSilencerMock()
+	_, err := silencer.Add(context.Background(), Silence{
+		Matchers:  m.Matchers,
+		StartAt:   now,
+		Duration:  m.Duration,
+		Comment:   legacy.Hash().String(),
+		CreatedBy: "maintenance service",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		[]Maintenance{m},
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, silencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err = maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	assert.Equal(t, []string{withID.Identity().String()}, silencer.comments())
+	assert.True(t, maintenanceService.WatchedMaintenances()[0].IsActive)
+}
+
+func TestMaintenanceService_ManagesOnlyOwnSilences(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	silencer := newSilencerMock()
+	comments := make([]string, 0)
+	for _, name := range []string{"team-a", "team-b"} {
+		instance, err := NewInstance(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		maintenanceService := NewMaintenanceService(
+			instance,
+			MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+			0,
+			0,
+			[]Target{NewTarget(DefaultTargetName, silencer)},
+			NewMemoryStateStore(),
+			NewMemoryHistory(ClockMock{now}),
+			ClockMock{now},
+			logrus.New(),
+		)
+
+		err = maintenanceService.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = maintenanceService.Stop(context.Background())
+
+		comments = append(comments, instance.Comment(maintenance.Identity()))
+	}
+
+	assert.ElementsMatch(t, comments, silencer.comments())
+}
+
+func TestMaintenanceService_Reconcile(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	active := YamlMaintenance{
+		Matchers: []string{"alertname=active"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	inactive := YamlMaintenance{
+		Matchers: []string{"alertname=inactive"},
+		Schedule: "* * * * *",
+		Duration: "10s",
+	}
+
+	silencer := newSilencerMock()
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{active, inactive})),
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, silencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	// silence expired by hand and silence of inactive maintenance created by hand
+	for id := range silencer.silences {
+		_ = silencer.Delete(context.Background(), id)
+	}
+	_, _ = silencer.Add(context.Background(), Silence{
+		Comment:   inactive.Hash().String(),
+		CreatedBy: "maintenance service",
+	})
+
+	err = maintenanceService.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{active.Hash().String()}, silencer.comments())
+
+	watched := make(map[MaintenanceHash]bool)
+	for _, m := range maintenanceService.WatchedMaintenances() {
+		watched[m.Maintenance.Hash] = m.IsActive
+	}
+	assert.Equal(t, map[MaintenanceHash]bool{
+		active.Hash():   true,
+		inactive.Hash(): false,
+	}, watched)
+}
+
+func TestMaintenanceService_Lookahead(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	silencer := newSilencerMock()
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		3*time.Minute,
+		0,
+		[]Target{NewTarget(DefaultTargetName, silencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	expected := []time.Time{
+		now.Truncate(time.Minute),
+		now.Truncate(time.Minute).Add(time.Minute),
+		now.Truncate(time.Minute).Add(2 * time.Minute),
+		now.Truncate(time.Minute).Add(3 * time.Minute),
+	}
+	assert.ElementsMatch(t, expected, silencer.startTimes())
+
+	// reconciliation keeps pending silences
+	err = maintenanceService.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.ElementsMatch(t, expected, silencer.startTimes())
+
+	// silences of removed maintenance are deleted along with pending ones
+	maintenanceService.Reload(nil)
+	assert.Empty(t, silencer.startTimes())
+}
+
+func TestMaintenanceService_WindowOverrides(t *testing.T) {
+	now := time.Date(2021, 4, 7, 2, 30, 0, 0, time.UTC)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+
+	silencer := newSilencerMock()
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, silencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	hash := maintenance.Hash()
+	assert.Equal(t, ErrMaintenanceNotActive, maintenanceService.EndNow(hash, "alice"))
+	assert.Equal(t, ErrMaintenanceNotFound, maintenanceService.StartNow(MaintenanceHash{}, "alice"))
+
+	// started ahead of schedule
+	assert.NoError(t, maintenanceService.StartNow(hash, "alice"))
+	assert.Equal(t, ErrMaintenanceActive, maintenanceService.StartNow(hash, "alice"))
+	assert.Equal(t, []time.Time{now}, silencer.startTimes())
+
+	watched := maintenanceService.WatchedMaintenances()[0]
+	assert.True(t, watched.IsActive)
+	assert.Equal(t, Window{now, now.Add(time.Hour)}, watched.Window)
+	assert.Equal(t, OverrideActionStart, watched.Override.Action)
+	assert.Equal(t, "alice", watched.Override.Actor)
+
+	// reconciliation keeps silence of overridden window
+	err = maintenanceService.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []time.Time{now}, silencer.startTimes())
+
+	// extended in place
+	silenceID := watched.Targets[0].SilenceID
+	assert.Equal(t, ErrEndNotInFuture, maintenanceService.Extend(hash, now, "bob"))
+	assert.NoError(t, maintenanceService.Extend(hash, now.Add(2*time.Hour), "bob"))
+
+	watched = maintenanceService.WatchedMaintenances()[0]
+	assert.Equal(t, silenceID, watched.Targets[0].SilenceID)
+	assert.Equal(t, 2*time.Hour, silencer.silences[silenceID].Duration)
+	assert.Equal(t, OverrideActionExtend, watched.Override.Action)
+	assert.Equal(t, "bob", watched.Override.Actor)
+
+	// ended early
+	assert.NoError(t, maintenanceService.EndNow(hash, "carol"))
+	assert.Empty(t, silencer.startTimes())
+
+	err = maintenanceService.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, silencer.startTimes())
+
+	watched = maintenanceService.WatchedMaintenances()[0]
+	assert.False(t, watched.IsActive)
+	assert.Equal(t, OverrideActionEnd, watched.Override.Action)
+	assert.Equal(t, "carol", watched.Override.Actor)
+}
+
+func TestMaintenanceService_RestoresState(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+
+	silencer := newSilencerMock()
+	store := NewMemoryStateStore()
+	start := func() *MaintenanceService {
+		maintenanceService := NewMaintenanceService(
+			Instance{},
+			MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+			0,
+			0,
+			[]Target{NewTarget(DefaultTargetName, silencer)},
+			store,
+			NewMemoryHistory(ClockMock{now}),
+			ClockMock{now},
+			logrus.New(),
+		)
+
+		err := maintenanceService.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return maintenanceService
+	}
+
+	maintenanceService := start()
+	err := maintenanceService.Extend(maintenance.Hash(), now.Add(2*time.Hour), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	silenceID := maintenanceService.WatchedMaintenances()[0].Targets[0].SilenceID
+	_ = maintenanceService.Stop(context.Background())
+
+	// extended window is resumed, its silence is neither expired nor recreated
+	maintenanceService = start()
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	watched := maintenanceService.WatchedMaintenances()[0]
+	assert.True(t, watched.IsActive)
+	assert.Equal(t, silenceID, watched.Targets[0].SilenceID)
+	assert.Equal(t, Window{now.Add(-10 * time.Minute), now.Add(2 * time.Hour)}, watched.Window)
+	assert.Equal(t, "alice", watched.Override.Actor)
+	assert.Len(t, silencer.comments(), 1)
+}
+
+func TestMaintenanceService_History(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+
+	history := NewMemoryHistory(ClockMock{now})
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, newSilencerMock())},
+		NewMemoryStateStore(),
+		history,
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	err = maintenanceService.EndNow(maintenance.Hash(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := history.Entries(HistoryFilter{})
+	events := make([]string, len(entries))
+	for i, e := range entries {
+		events[i] = e.Event + "/" + e.Action + "/" + e.Actor
+	}
+	assert.Equal(t, []string{"silence_created/reconcile/", "manual/end/alice", "silence_deleted/end/alice"}, events)
+	assert.Equal(t, entries[0].SilenceID, entries[2].SilenceID)
+}
+
+func TestMaintenanceService_RecoversWhenAlertmanagerIsBack(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+
+	silencer := &unreachableSilencerMock{silencerMock: newSilencerMock(), failures: 2}
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, silencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+	maintenanceService.recoveryInterval = time.Millisecond
+
+	err := maintenanceService.Start()
+	assert.NoError(t, err, "unreachable alertmanager is not fatal")
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	assert.Eventually(t, func() bool {
+		return maintenanceService.Ready() == nil
+	}, time.Second, time.Millisecond)
+	assert.True(t, maintenanceService.WatchedMaintenances()[0].IsActive)
+	assert.Len(t, silencer.comments(), 1)
+
+	assert.NoError(t, maintenanceService.Stop(context.Background()))
+	assert.NoError(t, maintenanceService.Stop(context.Background()), "service could be stopped twice")
+}
+
+func TestMaintenanceService_Targets(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	healthy := newSilencerMock()
+	failing := &failingSilencerMock{errors: []error{errors.New("connection refused")}}
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		0,
+		0,
+		[]Target{{DefaultTargetName, "healthy", healthy}, {DefaultTargetName, "failing", failing}},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	assert.Equal(t, []string{maintenance.Hash().String()}, healthy.comments())
+
+	watched := maintenanceService.WatchedMaintenances()[0]
+	assert.True(t, watched.IsActive)
+	assert.EqualError(t, watched.LastError, "connection refused")
+	assert.Len(t, watched.Targets, 2)
+	assert.True(t, watched.Targets[0].IsActive)
+	assert.NoError(t, watched.Targets[0].LastError)
+	assert.False(t, watched.Targets[1].IsActive)
+	assert.EqualError(t, watched.Targets[1].LastError, "connection refused")
+}
+
+func TestMaintenanceService_Routing(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	maintenance := YamlMaintenance{
+		ID:       "test",
+		Matchers: []string{"alertname=test"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	routed := maintenance
+	routed.Alertmanager = "tenant-a"
+
+	defaultSilencer := newSilencerMock()
+	tenantSilencer := newSilencerMock()
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, defaultSilencer), NewTarget("tenant-a", tenantSilencer)},
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	err := maintenanceService.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	assert.Len(t, defaultSilencer.comments(), 1)
+	assert.Empty(t, tenantSilencer.comments())
+
+	// maintenance moved to other alertmanager leaves the previous one
+	maintenanceService.Reload(MustMaintenances(ParseMaintenances([]YamlMaintenance{routed})))
+
+	assert.Empty(t, defaultSilencer.comments())
+	assert.Len(t, tenantSilencer.comments(), 1)
+
+	watched := maintenanceService.WatchedMaintenances()[0]
+	assert.True(t, watched.IsActive)
+	assert.Equal(t, []WatchedTarget{{
+		Name:      "tenant-a",
+		IsActive:  true,
+		SilenceID: watched.Targets[0].SilenceID,
+	}}, watched.Targets)
+}
+
+func TestMaintenanceService_OperationTimeout(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	maintenance := YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	}
+
+	targets := make([]Target, 0)
+	for _, name := range []string{"a", "b", "c"} {
+		targets = append(targets, Target{DefaultTargetName, name, hangingSilencerMock{}})
+	}
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+		0,
+		200*time.Millisecond,
+		targets,
+		NewMemoryStateStore(),
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+
+	startedAt := time.Now()
+	err := maintenanceService.Start()
+	assert.NoError(t, err)
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+
+	assert.Less(t, int64(time.Since(startedAt)), int64(500*time.Millisecond), "targets share the deadline")
+	assert.Error(t, maintenanceService.Ready())
+}
+
+type silencerMock struct {
+	silences map[ActiveSilenceID]Silence
+	mux      sync.Mutex
+}
+
+func newSilencerMock() *silencerMock {
+	return &silencerMock{
+		silences: make(map[ActiveSilenceID]Silence),
+	}
+}
+
+func (m *silencerMock) Add(_ context.Context, silence Silence) (ActiveSilenceID, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	id := ActiveSilenceID(uuid.NewV4().String())
+	m.silences[id] = silence
+	return id, nil
+}
+
+func (m *silencerMock) Delete(_ context.Context, id ActiveSilenceID) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	delete(m.silences, id)
+	return nil
+}
+
+// SetEnd updates silence in place like Alertmanager does for active silences
+func (m *silencerMock) SetEnd(_ context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	silence, ok := m.silences[id]
+	if !ok {
+		return "", errors.New("silence not found")
+	}
+
+	silence.Duration = endsAt.Sub(silence.StartAt)
+	m.silences[id] = silence
+	return id, nil
+}
+
+func (m *silencerMock) ActiveSilences(_ context.Context, createdBy string) ([]ActiveSilence, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	result := make([]ActiveSilence, 0)
+	for id, s := range m.silences {
+		if s.CreatedBy != createdBy {
+			continue
+		}
+
+		result = append(result, ActiveSilence{id, s.Comment, s.Matchers, s.StartAt, s.StartAt.Add(s.Duration)})
+	}
+
+	return result, nil
+}
+
+func (m *silencerMock) comments() []string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	result := make([]string, 0, len(m.silences))
+	for _, s := range m.silences {
+		result = append(result, s.Comment)
+	}
+
+	return result
+}
+
+func (m *silencerMock) startTimes() []time.Time {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	result := make([]time.Time, 0, len(m.silences))
+	for _, s := range m.silences {
+		result = append(result, s.StartAt)
+	}
+
+	return result
+}
+
+// unreachableSilencerMock fails to list silences given number of times
+type unreachableSilencerMock struct {
+	*silencerMock
+	failures int
+}
+
+func (m *unreachableSilencerMock) ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error) {
+	m.mux.Lock()
+	if m.failures > 0 {
+		m.failures--
+		m.mux.Unlock()
+		return nil, errors.New("connection refused")
+	}
+	m.mux.Unlock()
+
+	return m.silencerMock.ActiveSilences(ctx, createdBy)
+}
+
+// hangingSilencerMock never answers, calls end with their context
+type hangingSilencerMock struct{}
+
+func (hangingSilencerMock) Add(ctx context.Context, _ Silence) (ActiveSilenceID, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (hangingSilencerMock) Delete(ctx context.Context, _ ActiveSilenceID) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (hangingSilencerMock) SetEnd(ctx context.Context, _ ActiveSilenceID, _ time.Time) (ActiveSilenceID, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (hangingSilencerMock) ActiveSilences(ctx context.Context, _ string) ([]ActiveSilence, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
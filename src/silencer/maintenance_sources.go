@@ -0,0 +1,113 @@
+package silencer
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MaintenanceSourceFile     = "file"
+	MaintenanceSourceCalendar = "calendar"
+	MaintenanceSourceAPI      = "api"
+)
+
+type maintenanceSource interface {
+	Set(yamlMaintenances []YamlMaintenance, maintenances []Maintenance) error
+}
+
+type maintenanceSourcesSetter interface {
+	Set(contents map[string]SourceMaintenances) error
+}
+
+// MaintenanceSources combines maintenances of config file, calendar files and runtime ones created via API.
+// Every change of a source reloads maintenance service with maintenances of all sources.
+type MaintenanceSources struct {
+	maintenanceReloader  maintenanceReloader
+	yamlMaintenanceIndex *ReloadableYamlMaintenanceIndex
+
+	names   []string
+	sources map[string]SourceMaintenances
+	mux     sync.Mutex
+}
+
+// SourceMaintenances are maintenances of a single source.
+type SourceMaintenances struct {
+	YamlMaintenances []YamlMaintenance
+	Maintenances     []Maintenance
+}
+
+func NewMaintenanceSources(
+	maintenanceReloader maintenanceReloader,
+	yamlMaintenanceIndex *ReloadableYamlMaintenanceIndex,
+	names ...string,
+) *MaintenanceSources {
+	return &MaintenanceSources{
+		maintenanceReloader:  maintenanceReloader,
+		yamlMaintenanceIndex: yamlMaintenanceIndex,
+		names:                names,
+		sources:              make(map[string]SourceMaintenances),
+	}
+}
+
+// Source returns handle, which replaces maintenances of named source.
+func (s *MaintenanceSources) Source(name string) *MaintenanceSource {
+	return &MaintenanceSource{
+		name,
+		s,
+	}
+}
+
+// Set replaces maintenances of several sources at once, so maintenance service is reloaded once
+// and either all of them are replaced or none. Maintenance identity must be unique across all sources,
+// maintenance could move between replaced sources.
+func (s *MaintenanceSources) Set(contents map[string]SourceMaintenances) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	sources := make(map[string]SourceMaintenances, len(s.sources))
+	for name, items := range s.sources {
+		sources[name] = items
+	}
+	for source, content := range contents {
+		sourced := make([]Maintenance, len(content.Maintenances))
+		for i, m := range content.Maintenances {
+			m.Source = source
+			sourced[i] = m
+		}
+		sources[source] = SourceMaintenances{content.YamlMaintenances, sourced}
+	}
+
+	all := make([]Maintenance, 0)
+	allYaml := make([]YamlMaintenance, 0)
+	owners := make(map[MaintenanceHash]string)
+	for _, name := range s.names {
+		for _, m := range sources[name].Maintenances {
+			if owner, ok := owners[m.Hash]; ok {
+				return errors.Errorf("maintenance %s of %s is already defined by %s", m.Hash, name, owner)
+			}
+			owners[m.Hash] = name
+		}
+
+		all = append(all, sources[name].Maintenances...)
+		allYaml = append(allYaml, sources[name].YamlMaintenances...)
+	}
+
+	s.sources = sources
+	s.yamlMaintenanceIndex.Set(BuildYamlMaintenanceIndex(allYaml))
+	s.maintenanceReloader.Reload(all)
+
+	return nil
+}
+
+// MaintenanceSource is a single source of maintenances.
+type MaintenanceSource struct {
+	name    string
+	sources *MaintenanceSources
+}
+
+func (s *MaintenanceSource) Set(yamlMaintenances []YamlMaintenance, maintenances []Maintenance) error {
+	return s.sources.Set(map[string]SourceMaintenances{
+		s.name: {yamlMaintenances, maintenances},
+	})
+}
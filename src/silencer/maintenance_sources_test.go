@@ -0,0 +1,83 @@
+package silencer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type maintenanceReloaderMock struct {
+	reloads [][]Maintenance
+}
+
+func (m *maintenanceReloaderMock) Reload(maintenances []Maintenance) {
+	m.reloads = append(m.reloads, maintenances)
+}
+
+func TestMaintenanceSources_Set(t *testing.T) {
+	backup := YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"job=db"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+	}
+	deploy := YamlMaintenance{
+		ID:       "deploy",
+		Matchers: []string{"job=web"},
+		Schedule: "0 4 * * *",
+		Duration: "1h",
+	}
+	content := func(items ...YamlMaintenance) SourceMaintenances {
+		return SourceMaintenances{items, MustMaintenances(ParseMaintenances(items))}
+	}
+	sourceOf := func(maintenances []Maintenance) map[string]string {
+		result := make(map[string]string)
+		for _, m := range maintenances {
+			result[m.ID] = m.Source
+		}
+		return result
+	}
+
+	reloader := &maintenanceReloaderMock{}
+	sources := NewMaintenanceSources(
+		reloader,
+		NewReloadableYamlMaintenanceIndex(YamlMaintenanceIndex{}),
+		MaintenanceSourceFile,
+		MaintenanceSourceCalendar,
+		MaintenanceSourceAPI,
+	)
+
+	err := sources.Set(map[string]SourceMaintenances{
+		MaintenanceSourceFile:     content(backup),
+		MaintenanceSourceCalendar: content(deploy),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, reloader.reloads, 1, "sources are reloaded once")
+	assert.Equal(t, map[string]string{
+		"backup": MaintenanceSourceFile,
+		"deploy": MaintenanceSourceCalendar,
+	}, sourceOf(reloader.reloads[0]))
+
+	err = sources.Set(map[string]SourceMaintenances{
+		MaintenanceSourceFile:     content(backup, deploy),
+		MaintenanceSourceCalendar: content(),
+	})
+	assert.NoError(t, err, "maintenance moves from calendar to file")
+	assert.Len(t, reloader.reloads, 2)
+	assert.Equal(t, map[string]string{
+		"backup": MaintenanceSourceFile,
+		"deploy": MaintenanceSourceFile,
+	}, sourceOf(reloader.reloads[1]))
+
+	err = sources.Set(map[string]SourceMaintenances{
+		MaintenanceSourceFile:     content(),
+		MaintenanceSourceCalendar: content(deploy),
+		MaintenanceSourceAPI:      content(deploy),
+	})
+	assert.Error(t, err)
+	assert.Len(t, reloader.reloads, 2, "none of sources is replaced")
+
+	err = sources.Source(MaintenanceSourceAPI).Set(content(backup).YamlMaintenances, content(backup).Maintenances)
+	assert.Error(t, err, "maintenance is already defined by file")
+	assert.Len(t, reloader.reloads, 2)
+}
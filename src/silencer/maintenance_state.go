@@ -0,0 +1,98 @@
+package silencer
+
+import (
+	"time"
+)
+
+const (
+	overridesStateKey    = "overrides"
+	targetStateKeyPrefix = "target:"
+)
+
+// persistedTargetState is state of maintenance silences in target kept in state store
+type persistedTargetState struct {
+	Active  []persistedSilence `json:"active"`
+	Pending []persistedSilence `json:"pending"`
+}
+
+// persistedSilence is silence of maintenance window, end of pending window is not kept
+type persistedSilence struct {
+	Maintenance MaintenanceHash `json:"maintenance"`
+	SilenceID   ActiveSilenceID `json:"silenceId"`
+	StartAt     time.Time       `json:"startAt"`
+	EndAt       time.Time       `json:"endAt"`
+}
+
+// saveState persists silences of every target along with window overrides. It is called with silencesMux locked,
+// nothing is saved until service is started, so state is not overwritten before it is restored.
+func (s *MaintenanceService) saveState() {
+	if !s.started {
+		return
+	}
+
+	err := s.store.Put(overridesStateKey, s.overrides)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to save window overrides")
+	}
+
+	for _, t := range s.targets {
+		state := persistedTargetState{
+			Active:  make([]persistedSilence, 0, len(t.activeWindows)),
+			Pending: make([]persistedSilence, 0, len(t.pendingSilences)),
+		}
+
+		for hash, w := range t.activeWindows {
+			silenceID, ok := t.activeMaintenanceStorage.Get(hash)
+			if !ok {
+				continue
+			}
+
+			state.Active = append(state.Active, persistedSilence{hash, silenceID, w.StartAt, w.EndAt})
+		}
+
+		for key, silenceID := range t.pendingSilences {
+			state.Pending = append(state.Pending, persistedSilence{key.hash, silenceID, time.Unix(key.startAt, 0), time.Time{}})
+		}
+
+		err := s.store.Put(targetStateKeyPrefix+t.Name(), state)
+		if err != nil {
+			t.logger.WithError(err).Error("failed to save state")
+		}
+	}
+}
+
+// restoreState brings back window overrides and silences of windows, which are not over yet. Expire timers
+// of active windows are rearmed, silences are checked against Alertmanager by reconciliation afterwards.
+func (s *MaintenanceService) restoreState() error {
+	overrides := make(map[MaintenanceHash]WindowOverride)
+	_, err := s.store.Get(overridesStateKey, &overrides)
+	if err != nil {
+		return err
+	}
+	s.overrides = overrides
+
+	now := s.clock.Now()
+	maintenances := buildMaintenanceIndex(s.maintenances)
+	for _, t := range s.targets {
+		state := persistedTargetState{}
+		_, err := s.store.Get(targetStateKeyPrefix+t.Name(), &state)
+		if err != nil {
+			return err
+		}
+
+		for _, silence := range state.Active {
+			m, ok := maintenances[silence.Maintenance]
+			if !ok || !t.Routes(m) || !now.Before(silence.EndAt) {
+				continue
+			}
+
+			s.watchSilence(t, m, Window{silence.StartAt, silence.EndAt}, silence.SilenceID)
+		}
+
+		for _, silence := range state.Pending {
+			t.pendingSilences[newOccurrence(silence.Maintenance, silence.StartAt)] = silence.SilenceID
+		}
+	}
+
+	return nil
+}
@@ -51,3 +51,386 @@ func mustParseSchedule(s cron.Schedule, err error) cron.Schedule {
 
 	return s
 }
+
+func TestMaintenance_OneOff(t *testing.T) {
+	start := time.Date(2021, 4, 7, 1, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 4, 7, 5, 0, 0, 0, time.UTC)
+
+	m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Start:    start.Format(time.RFC3339),
+		End:      end.Format(time.RFC3339),
+	}))
+
+	testCases := []struct {
+		name       string
+		at         time.Time
+		isActive   bool
+		isFinished bool
+		next       time.Time
+	}{
+		{
+			name:     "upcoming",
+			at:       start.Add(-time.Hour),
+			isActive: false,
+			next:     start,
+		},
+		{
+			name:     "active",
+			at:       start.Add(time.Hour),
+			isActive: true,
+		},
+		{
+			name:       "finished",
+			at:         end.Add(time.Hour),
+			isActive:   false,
+			isFinished: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			isActive, _ := m.ActiveAt(tc.at)
+			assert.Equal(t, tc.isActive, isActive)
+			assert.Equal(t, tc.isFinished, m.FinishedAt(tc.at))
+			assert.True(t, tc.next.Equal(m.Schedule.Next(tc.at)))
+		})
+	}
+}
+
+func TestZonedSchedule_Next(t *testing.T) {
+	newYork := mustLoadLocation(time.LoadLocation("America/New_York"))
+	tokyo := mustLoadLocation(time.LoadLocation("Asia/Tokyo"))
+
+	testCases := []struct {
+		name     string
+		schedule string
+		location *time.Location
+		after    time.Time
+		next     time.Time
+	}{
+		{
+			name:     "start time is evaluated in maintenance time zone",
+			schedule: "0 3 * * *",
+			location: tokyo,
+			after:    time.Date(2021, 4, 7, 0, 0, 0, 0, time.UTC),
+			next:     time.Date(2021, 4, 7, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "start time within skipped hour is moved to the end of the gap",
+			schedule: "30 2 * * *",
+			location: newYork,
+			after:    time.Date(2021, 3, 14, 0, 0, 0, 0, newYork),
+			next:     time.Date(2021, 3, 14, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day after skipped hour is not affected",
+			schedule: "30 2 * * *",
+			location: newYork,
+			after:    time.Date(2021, 3, 14, 7, 0, 0, 0, time.UTC),
+			next:     time.Date(2021, 3, 15, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "start time within repeated hour fires at its first occurrence",
+			schedule: "30 1 * * *",
+			location: newYork,
+			after:    time.Date(2021, 11, 7, 0, 0, 0, 0, newYork),
+			next:     time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "start time within repeated hour does not fire twice",
+			schedule: "30 1 * * *",
+			location: newYork,
+			after:    time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC),
+			next:     time.Date(2021, 11, 8, 6, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+				Matchers: []string{"alertname=test"},
+				Schedule: tc.schedule,
+				Duration: "1h",
+				Timezone: tc.location.String(),
+			}))
+
+			next := m.Schedule.Next(tc.after)
+			assert.True(t, tc.next.Equal(next), "expected %s, got %s", tc.next, next)
+			assert.Equal(t, tc.location, next.Location())
+		})
+	}
+}
+
+func TestRecurrenceSchedule_Next(t *testing.T) {
+	newYork := mustLoadLocation(time.LoadLocation("America/New_York"))
+	tokyo := mustLoadLocation(time.LoadLocation("Asia/Tokyo"))
+
+	testCases := []struct {
+		name       string
+		recurrence string
+		location   *time.Location
+		after      time.Time
+		next       time.Time
+	}{
+		{
+			name:       "last Sunday of month",
+			recurrence: "DTSTART=20210103T030000;FREQ=MONTHLY;BYDAY=-1SU",
+			location:   time.UTC,
+			after:      time.Date(2021, 4, 7, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2021, 4, 25, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "first Monday of month",
+			recurrence: "RRULE:DTSTART=20210104T020000;FREQ=MONTHLY;BYDAY=1MO",
+			location:   time.UTC,
+			after:      time.Date(2021, 4, 7, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2021, 5, 3, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "last day of month",
+			recurrence: "DTSTART=20210131T230000;FREQ=MONTHLY;BYMONTHDAY=-1",
+			location:   time.UTC,
+			after:      time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2021, 2, 28, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "every other Saturday is anchored at DTSTART",
+			recurrence: "DTSTART=20210102T220000;FREQ=WEEKLY;INTERVAL=2;BYDAY=SA",
+			location:   tokyo,
+			after:      time.Date(2021, 4, 7, 0, 0, 0, 0, tokyo),
+			next:       time.Date(2021, 4, 10, 22, 0, 0, 0, tokyo),
+		},
+		{
+			name:       "every other Saturday skips a week",
+			recurrence: "DTSTART=20210102T220000;FREQ=WEEKLY;INTERVAL=2;BYDAY=SA",
+			location:   tokyo,
+			after:      time.Date(2021, 4, 10, 22, 0, 0, 0, tokyo),
+			next:       time.Date(2021, 4, 24, 22, 0, 0, 0, tokyo),
+		},
+		{
+			name:       "DTSTART in UTC is converted to maintenance time zone",
+			recurrence: "DTSTART=20210102T200000Z;FREQ=WEEKLY",
+			location:   tokyo,
+			after:      time.Date(2021, 4, 7, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2021, 4, 10, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "start time within skipped hour is moved to the end of the gap",
+			recurrence: "DTSTART=20210110T023000;FREQ=MONTHLY;BYDAY=2SU",
+			location:   newYork,
+			after:      time.Date(2021, 3, 1, 0, 0, 0, 0, newYork),
+			next:       time.Date(2021, 3, 14, 7, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+				Matchers:   []string{"alertname=test"},
+				Recurrence: tc.recurrence,
+				Duration:   "1h",
+				Timezone:   tc.location.String(),
+			}))
+
+			next := m.Schedule.Next(tc.after)
+			assert.True(t, tc.next.Equal(next), "expected %s, got %s", tc.next, next)
+			assert.Equal(t, tc.location, next.Location())
+		})
+	}
+}
+
+func TestRecurrenceSchedule_Bounded(t *testing.T) {
+	m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		Matchers:   []string{"alertname=test"},
+		Recurrence: "DTSTART=20210102T220000;FREQ=WEEKLY;COUNT=2",
+		Duration:   "1h",
+		Timezone:   "UTC",
+	}))
+	last := time.Date(2021, 1, 9, 22, 0, 0, 0, time.UTC)
+
+	isActive, startAt := m.ActiveAt(last.Add(30 * time.Minute))
+	assert.True(t, isActive)
+	assert.Equal(t, last, startAt)
+	assert.False(t, m.FinishedAt(last.Add(30*time.Minute)))
+
+	isActive, _ = m.ActiveAt(last.Add(2 * time.Hour))
+	assert.False(t, isActive)
+	assert.True(t, m.FinishedAt(last.Add(2*time.Hour)))
+}
+
+func TestParseMaintenance_InvalidRecurrence(t *testing.T) {
+	testCases := []struct {
+		name        string
+		maintenance YamlMaintenance
+	}{
+		{
+			name:        "DTSTART is missing",
+			maintenance: YamlMaintenance{Recurrence: "FREQ=MONTHLY;BYDAY=-1SU", Duration: "1h"},
+		},
+		{
+			name:        "frequent recurrence",
+			maintenance: YamlMaintenance{Recurrence: "DTSTART=20210102T220000;FREQ=HOURLY", Duration: "1h"},
+		},
+		{
+			name:        "invalid rule",
+			maintenance: YamlMaintenance{Recurrence: "DTSTART=20210102T220000;FREQ=FORTNIGHTLY", Duration: "1h"},
+		},
+		{
+			name: "combined with schedule",
+			maintenance: YamlMaintenance{
+				Recurrence: "DTSTART=20210102T220000;FREQ=WEEKLY",
+				Schedule:   "0 22 * * 6",
+				Duration:   "1h",
+			},
+		},
+		{
+			name: "combined with one-off window",
+			maintenance: YamlMaintenance{
+				Recurrence: "DTSTART=20210102T220000;FREQ=WEEKLY",
+				Start:      "2021-01-02T22:00:00Z",
+				End:        "2021-01-02T23:00:00Z",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.maintenance.Matchers = []string{"alertname=test"}
+			_, err := ParseMaintenance(tc.maintenance)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExceptionSchedule_Next(t *testing.T) {
+	m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		Matchers: []string{"alertname=vacuum"},
+		Schedule: "0 3 * * 0",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	sunday := time.Date(2021, 4, 11, 3, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	m = withExceptions(m, []OccurrenceException{
+		{ScheduledAt: sunday, Action: ExceptionActionSkip},
+		{ScheduledAt: sunday.Add(week), Action: ExceptionActionPostpone, StartAt: sunday.Add(week + 20*time.Hour)},
+	})
+
+	expected := []Window{
+		{sunday.Add(week + 20*time.Hour), sunday.Add(week + 21*time.Hour)},
+		{sunday.Add(2 * week), sunday.Add(2*week + time.Hour)},
+	}
+	assert.Equal(t, expected, m.Windows(sunday.Add(-time.Hour), sunday.Add(2*week)))
+
+	isActive, _ := m.ActiveAt(sunday.Add(30 * time.Minute))
+	assert.False(t, isActive, "skipped occurrence")
+
+	// one-off maintenance postponed beyond its original end is not finished
+	oneOff := withExceptions(MustMaintenance(ParseMaintenance(YamlMaintenance{
+		Matchers: []string{"alertname=migration"},
+		Start:    sunday.Format(time.RFC3339),
+		End:      sunday.Add(time.Hour).Format(time.RFC3339),
+	})), []OccurrenceException{
+		{ScheduledAt: sunday, Action: ExceptionActionPostpone, StartAt: sunday.Add(week)},
+	})
+	assert.True(t, oneOff.IsOneOff())
+	assert.False(t, oneOff.FinishedAt(sunday.Add(2*time.Hour)))
+	assert.True(t, oneOff.FinishedAt(sunday.Add(week+time.Hour)))
+}
+
+func TestMaintenance_IsActiveAtAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(time.LoadLocation("America/New_York"))
+
+	m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		Matchers: []string{"alertname=test"},
+		Schedule: "0 1 * * *",
+		Duration: "2h",
+		Timezone: "America/New_York",
+	}))
+
+	// clocks jump from 02:00 to 03:00, window started at 01:00 lasts 2 hours and ends at 04:00
+	isActive, startAt := m.ActiveAt(time.Date(2021, 3, 14, 3, 59, 0, 0, newYork))
+	assert.True(t, isActive)
+	assert.True(t, time.Date(2021, 3, 14, 1, 0, 0, 0, newYork).Equal(startAt))
+
+	isActive, _ = m.ActiveAt(time.Date(2021, 3, 14, 4, 1, 0, 0, newYork))
+	assert.False(t, isActive)
+}
+
+func TestYamlMaintenance_Identity(t *testing.T) {
+	maintenance := YamlMaintenance{
+		ID:       "db-vacuum",
+		Matchers: []string{"alertname=test", "instance=db"},
+		Schedule: "0 3 * * 0",
+		Duration: "1h",
+	}
+
+	edited := maintenance
+	edited.Matchers = []string{"instance=db", "alertname=test"}
+	edited.Duration = "60m"
+
+	assert.Equal(t, maintenance.Identity(), edited.Identity())
+	assert.NotEqual(t, maintenance.Hash(), edited.Hash())
+
+	maintenance.ID = ""
+	assert.Equal(t, maintenance.Hash(), maintenance.Identity())
+}
+
+func mustLoadLocation(location *time.Location, err error) *time.Location {
+	if err != nil {
+		panic(err)
+	}
+
+	return location
+}
+
+func TestConfigFromYaml_Alertmanagers(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      YamlConfig
+		expectedErr bool
+	}{
+		{
+			name: "default alertmanager",
+			config: YamlConfig{
+				Maintenances: []YamlMaintenance{
+					{Matchers: []string{"alertname=test"}, Schedule: "* * * * *", Duration: "1m"},
+				},
+			},
+		},
+		{
+			name: "named alertmanager",
+			config: YamlConfig{
+				Alertmanagers: map[string]*YamlAlertmanager{"tenant-a": {URL: "http://tenant-a:9093"}},
+				Maintenances: []YamlMaintenance{
+					{Matchers: []string{"alertname=test"}, Schedule: "* * * * *", Duration: "1m", Alertmanager: "tenant-a"},
+				},
+			},
+		},
+		{
+			name: "unknown alertmanager",
+			config: YamlConfig{
+				Maintenances: []YamlMaintenance{
+					{Matchers: []string{"alertname=test"}, Schedule: "* * * * *", Duration: "1m", Alertmanager: "tenant-a"},
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "reserved name",
+			config: YamlConfig{
+				Alertmanagers: map[string]*YamlAlertmanager{DefaultTargetName: {URL: "http://tenant-a:9093"}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ConfigFromYaml(tc.config)
+			assert.Equal(t, tc.expectedErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package silencer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "silencer"
+
+// Statuses of Alertmanager calls
+const (
+	CallStatusSuccess     = "success"
+	CallStatusClientError = "client_error"
+	CallStatusError       = "error"
+)
+
+// Metrics are metrics of silencer itself, maintenance gauges are collected by MaintenanceCollector.
+type Metrics struct {
+	alertmanagerCalls        *prometheus.CounterVec
+	alertmanagerCallDuration *prometheus.HistogramVec
+	reconcileDuration        *prometheus.HistogramVec
+	configReloadSuccess      prometheus.Gauge
+	configReloadSuccessTime  prometheus.Gauge
+	configHash               prometheus.Gauge
+}
+
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		alertmanagerCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "alertmanager_calls_total",
+			Help:      "Alertmanager calls by operation and status, every retry is counted.",
+		}, []string{"alertmanager", "url", "operation", "status"}),
+		alertmanagerCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "alertmanager_call_duration_seconds",
+			Help:      "Latency of Alertmanager calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"alertmanager", "url", "operation"}),
+		reconcileDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of periodic reconciliations of silences.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+		configReloadSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "config_last_reload_successful",
+			Help:      "Whether the last config reload attempt was successful.",
+		}),
+		configReloadSuccessTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful config reload.",
+		}),
+		configHash: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "config_hash",
+			Help:      "Hash of the loaded config file.",
+		}),
+	}
+
+	registerer.MustRegister(
+		m.alertmanagerCalls,
+		m.alertmanagerCallDuration,
+		m.reconcileDuration,
+		m.configReloadSuccess,
+		m.configReloadSuccessTime,
+		m.configHash,
+	)
+
+	return m
+}
+
+// ObserveConfigReload records outcome of config reload, checksum of config is exported as hash on success.
+func (m *Metrics) ObserveConfigReload(checksum [sha256.Size]byte, err error) {
+	if err != nil {
+		m.configReloadSuccess.Set(0)
+		return
+	}
+
+	m.configReloadSuccess.Set(1)
+	m.configReloadSuccessTime.SetToCurrentTime()
+	m.configHash.Set(checksumValue(checksum))
+}
+
+// ObserveReconcile records duration of reconciliation.
+func (m *Metrics) ObserveReconcile(duration time.Duration, err error) {
+	status := CallStatusSuccess
+	if err != nil {
+		status = CallStatusError
+	}
+
+	m.reconcileDuration.WithLabelValues(status).Observe(duration.Seconds())
+}
+
+// checksumValue takes the first 6 bytes of checksum, so it is represented by float exactly.
+func checksumValue(checksum [sha256.Size]byte) float64 {
+	b := make([]byte, 8)
+	copy(b[2:], checksum[:6])
+
+	return float64(binary.BigEndian.Uint64(b))
+}
+
+// InstrumentedSilencer counts Alertmanager calls and measures their latency.
+type InstrumentedSilencer struct {
+	silencer     silencer
+	alertmanager string
+	url          string
+	metrics      *Metrics
+}
+
+func NewInstrumentedSilencer(silencer silencer, alertmanager string, url string, metrics *Metrics) *InstrumentedSilencer {
+	return &InstrumentedSilencer{
+		silencer,
+		alertmanager,
+		url,
+		metrics,
+	}
+}
+
+func (s *InstrumentedSilencer) Add(ctx context.Context, silence Silence) (ActiveSilenceID, error) {
+	var id ActiveSilenceID
+	err := s.observe("post", func() error {
+		var err error
+		id, err = s.silencer.Add(ctx, silence)
+		return err
+	})
+
+	return id, err
+}
+
+func (s *InstrumentedSilencer) Delete(ctx context.Context, id ActiveSilenceID) error {
+	return s.observe("delete", func() error {
+		return s.silencer.Delete(ctx, id)
+	})
+}
+
+func (s *InstrumentedSilencer) SetEnd(ctx context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error) {
+	var newID ActiveSilenceID
+	err := s.observe("update", func() error {
+		var err error
+		newID, err = s.silencer.SetEnd(ctx, id, endsAt)
+		return err
+	})
+
+	return newID, err
+}
+
+func (s *InstrumentedSilencer) ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error) {
+	var activeSilences []ActiveSilence
+	err := s.observe("list", func() error {
+		var err error
+		activeSilences, err = s.silencer.ActiveSilences(ctx, createdBy)
+		return err
+	})
+
+	return activeSilences, err
+}
+
+func (s *InstrumentedSilencer) observe(operation string, call func() error) error {
+	start := time.Now()
+	err := call()
+	s.metrics.alertmanagerCallDuration.WithLabelValues(s.alertmanager, s.url, operation).Observe(time.Since(start).Seconds())
+
+	status := CallStatusSuccess
+	switch {
+	case IsPermanentError(err):
+		status = CallStatusClientError
+	case err != nil:
+		status = CallStatusError
+	}
+	s.metrics.alertmanagerCalls.WithLabelValues(s.alertmanager, s.url, operation, status).Inc()
+
+	return err
+}
+
+var (
+	maintenanceActiveDesc = prometheus.NewDesc(
+		metricsNamespace+"_maintenance_window_active",
+		"Whether window of maintenance is silenced now.",
+		[]string{"maintenance", "id"},
+		nil,
+	)
+	maintenanceNextStartDesc = prometheus.NewDesc(
+		metricsNamespace+"_maintenance_next_start_timestamp_seconds",
+		"Start of the next window of maintenance, it is absent for finished maintenances.",
+		[]string{"maintenance", "id"},
+		nil,
+	)
+)
+
+// MaintenanceCollector exports state of every maintenance on scrape.
+type MaintenanceCollector struct {
+	watchedMaintenanceStorage watchedMaintenanceStorage
+}
+
+func NewMaintenanceCollector(watchedMaintenanceStorage watchedMaintenanceStorage) *MaintenanceCollector {
+	return &MaintenanceCollector{watchedMaintenanceStorage}
+}
+
+func (c *MaintenanceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- maintenanceActiveDesc
+	ch <- maintenanceNextStartDesc
+}
+
+func (c *MaintenanceCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.watchedMaintenanceStorage.WatchedMaintenances() {
+		hash, id := m.Maintenance.Hash.String(), m.Maintenance.ID
+
+		active := 0.0
+		if m.IsActive {
+			active = 1
+		}
+		ch <- prometheus.MustNewConstMetric(maintenanceActiveDesc, prometheus.GaugeValue, active, hash, id)
+
+		if !m.Next.IsZero() {
+			next := float64(m.Next.Unix())
+			ch <- prometheus.MustNewConstMetric(maintenanceNextStartDesc, prometheus.GaugeValue, next, hash, id)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package silencer
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 10, 0, 0, time.UTC)
+	m := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(registry)
+	registry.MustRegister(NewMaintenanceCollector(watchedMaintenanceStorageMock{
+		items: []WatchedMaintenance{{Maintenance: m, Next: m.Schedule.Next(now), IsActive: true}},
+	}))
+
+	failing := &failingSilencerMock{errors: []error{errors.New("connection refused")}}
+	s := NewInstrumentedSilencer(failing, DefaultTargetName, "http://alertmanager:9093", metrics)
+	_, _ = s.Add(context.Background(), Silence{})
+	_, _ = s.Add(context.Background(), Silence{})
+	_ = s.Delete(context.Background(), "silence")
+
+	metrics.ObserveConfigReload(sha256.Sum256([]byte("maintenances: []")), nil)
+	metrics.ObserveReconcile(time.Second, nil)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := make(map[string]*dto.MetricFamily, len(families))
+	for _, f := range families {
+		index[f.GetName()] = f
+	}
+
+	calls := make(map[string]float64)
+	for _, metric := range index["silencer_alertmanager_calls_total"].GetMetric() {
+		labels := make(map[string]string)
+		for _, l := range metric.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		calls[labels["operation"]+"/"+labels["status"]] = metric.GetCounter().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"post/error": 1, "post/success": 1, "delete/success": 1}, calls)
+
+	durations := index["silencer_alertmanager_call_duration_seconds"].GetMetric()
+	assert.Len(t, durations, 2)
+
+	assert.Equal(t, 1.0, index["silencer_config_last_reload_successful"].GetMetric()[0].GetGauge().GetValue())
+	assert.NotZero(t, index["silencer_config_hash"].GetMetric()[0].GetGauge().GetValue())
+	assert.Equal(t, uint64(1), index["silencer_reconcile_duration_seconds"].GetMetric()[0].GetHistogram().GetSampleCount())
+
+	assert.Equal(t, 1.0, index["silencer_maintenance_window_active"].GetMetric()[0].GetGauge().GetValue())
+	next := index["silencer_maintenance_next_start_timestamp_seconds"].GetMetric()[0]
+	assert.Equal(t, float64(time.Date(2021, 4, 8, 3, 0, 0, 0, time.UTC).Unix()), next.GetGauge().GetValue())
+}
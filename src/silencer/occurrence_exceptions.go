@@ -0,0 +1,252 @@
+package silencer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const occurrenceExceptionsKey = "exceptions"
+
+// Actions of occurrence exception
+const (
+	ExceptionActionSkip     = "skip"
+	ExceptionActionPostpone = "postpone"
+)
+
+var (
+	ErrOccurrenceNotFound = errors.New("occurrence not found")
+	ErrExceptionNotFound  = errors.New("exception not found")
+)
+
+// OccurrenceException skips single scheduled window of maintenance or moves it to StartAt.
+type OccurrenceException struct {
+	Maintenance MaintenanceHash `json:"maintenance"`
+	ScheduledAt time.Time       `json:"scheduledAt"`
+	Action      string          `json:"action"`
+	StartAt     time.Time       `json:"startAt"`
+	Actor       string          `json:"actor"`
+	At          time.Time       `json:"at"`
+}
+
+// IsOverAt tells whether windows affected by exception are over at t.
+func (e OccurrenceException) IsOverAt(t time.Time, duration time.Duration) bool {
+	return !t.Before(e.ScheduledAt.Add(duration)) && (e.StartAt.IsZero() || !t.Before(e.StartAt.Add(duration)))
+}
+
+// withExceptions applies exceptions to schedule of maintenance, it is left as is when there are none.
+func withExceptions(m Maintenance, exceptions []OccurrenceException) Maintenance {
+	m.Schedule = baseSchedule(m.Schedule)
+	if len(exceptions) == 0 {
+		return m
+	}
+
+	schedule := ExceptionSchedule{
+		Schedule: m.Schedule,
+		Skipped:  make(map[int64]bool, len(exceptions)),
+		Added:    make([]time.Time, 0, len(exceptions)),
+	}
+	for _, e := range exceptions {
+		schedule.Skipped[e.ScheduledAt.Unix()] = true
+		if e.Action == ExceptionActionPostpone {
+			schedule.Added = append(schedule.Added, e.StartAt)
+		}
+	}
+	sort.Slice(schedule.Added, func(i, j int) bool {
+		return schedule.Added[i].Before(schedule.Added[j])
+	})
+
+	m.Schedule = schedule
+	return m
+}
+
+type occurrenceExceptionsSetter interface {
+	CheckOccurrence(hash MaintenanceHash, scheduledAt time.Time) error
+	SetOccurrenceExceptions(exceptions []OccurrenceException)
+	IsExceptionOver(e OccurrenceException, t time.Time) bool
+}
+
+// OccurrenceExceptions are skipped and postponed occurrences of maintenances. They are persisted to state store,
+// so they survive restarts.
+type OccurrenceExceptions struct {
+	store                      StateStore
+	occurrenceExceptionsSetter occurrenceExceptionsSetter
+	history                    historyRecorder
+	clock                      clock
+
+	items []OccurrenceException
+	mux   sync.Mutex
+}
+
+func NewOccurrenceExceptions(
+	store StateStore,
+	occurrenceExceptionsSetter occurrenceExceptionsSetter,
+	history historyRecorder,
+	clock clock,
+) *OccurrenceExceptions {
+	return &OccurrenceExceptions{
+		store:                      store,
+		occurrenceExceptionsSetter: occurrenceExceptionsSetter,
+		history:                    history,
+		clock:                      clock,
+	}
+}
+
+// Load reads persisted exceptions, ones, which are over, are dropped.
+func (e *OccurrenceExceptions) Load() error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	items := make([]OccurrenceException, 0)
+	_, err := e.store.Get(occurrenceExceptionsKey, &items)
+	if err != nil {
+		return errors.Wrap(err, "failed to load occurrence exceptions")
+	}
+
+	e.items = e.prune(items)
+	e.occurrenceExceptionsSetter.SetOccurrenceExceptions(e.items)
+
+	return nil
+}
+
+// Skip cancels upcoming occurrence of maintenance scheduled at scheduledAt.
+func (e *OccurrenceExceptions) Skip(hash MaintenanceHash, scheduledAt time.Time, actor string) (OccurrenceException, error) {
+	return e.add(OccurrenceException{
+		Maintenance: hash,
+		ScheduledAt: scheduledAt,
+		Action:      ExceptionActionSkip,
+		Actor:       actor,
+	})
+}
+
+// Postpone moves upcoming occurrence of maintenance scheduled at scheduledAt to startAt.
+func (e *OccurrenceExceptions) Postpone(
+	hash MaintenanceHash,
+	scheduledAt time.Time,
+	startAt time.Time,
+	actor string,
+) (OccurrenceException, error) {
+	return e.add(OccurrenceException{
+		Maintenance: hash,
+		ScheduledAt: scheduledAt,
+		Action:      ExceptionActionPostpone,
+		StartAt:     startAt,
+		Actor:       actor,
+	})
+}
+
+// Restore drops exception of occurrence, so it happens as scheduled.
+func (e *OccurrenceExceptions) Restore(hash MaintenanceHash, scheduledAt time.Time, actor string) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	i := e.index(hash, scheduledAt)
+	if i < 0 {
+		return ErrExceptionNotFound
+	}
+
+	items := append(make([]OccurrenceException, 0, len(e.items)), e.items[:i]...)
+	items = append(items, e.items[i+1:]...)
+
+	err := e.save(items)
+	if err != nil {
+		return err
+	}
+
+	e.history.Record(HistoryEntry{
+		Event:       HistoryEventManual,
+		Action:      HistoryActionRestore,
+		Maintenance: hash,
+		Actor:       actor,
+		Message:     "occurrence scheduled at " + scheduledAt.Format(time.RFC3339) + " is restored",
+	})
+
+	return nil
+}
+
+// add replaces exception of the same occurrence, if there is one.
+func (e *OccurrenceExceptions) add(exception OccurrenceException) (OccurrenceException, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	now := e.clock.Now()
+	if !exception.ScheduledAt.After(now) {
+		return OccurrenceException{}, InvalidMaintenanceError{errors.New("only upcoming occurrence could be changed")}
+	}
+	if exception.Action == ExceptionActionPostpone && !exception.StartAt.After(now) {
+		return OccurrenceException{}, InvalidMaintenanceError{errors.New("occurrence could be moved only to the future")}
+	}
+
+	err := e.occurrenceExceptionsSetter.CheckOccurrence(exception.Maintenance, exception.ScheduledAt)
+	if err != nil {
+		return OccurrenceException{}, err
+	}
+
+	exception.At = now
+	items := append(make([]OccurrenceException, 0, len(e.items)+1), e.items...)
+	if i := e.index(exception.Maintenance, exception.ScheduledAt); i >= 0 {
+		items[i] = exception
+	} else {
+		items = append(items, exception)
+	}
+
+	err = e.save(items)
+	if err != nil {
+		return OccurrenceException{}, err
+	}
+
+	message := "occurrence scheduled at " + exception.ScheduledAt.Format(time.RFC3339) + " is skipped"
+	if exception.Action == ExceptionActionPostpone {
+		message = "occurrence scheduled at " + exception.ScheduledAt.Format(time.RFC3339) +
+			" is postponed to " + exception.StartAt.Format(time.RFC3339)
+	}
+	e.history.Record(HistoryEntry{
+		Event:       HistoryEventManual,
+		Action:      exception.Action,
+		Maintenance: exception.Maintenance,
+		Actor:       exception.Actor,
+		Message:     message,
+	})
+
+	return exception, nil
+}
+
+func (e *OccurrenceExceptions) index(hash MaintenanceHash, scheduledAt time.Time) int {
+	for i, item := range e.items {
+		if item.Maintenance == hash && item.ScheduledAt.Equal(scheduledAt) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// save persists exceptions before they are applied, so applied ones are never lost. Exceptions, which are over,
+// are dropped.
+func (e *OccurrenceExceptions) save(items []OccurrenceException) error {
+	items = e.prune(items)
+	err := e.store.Put(occurrenceExceptionsKey, items)
+	if err != nil {
+		return err
+	}
+
+	e.items = items
+	e.occurrenceExceptionsSetter.SetOccurrenceExceptions(items)
+
+	return nil
+}
+
+// prune drops exceptions, which windows are over, so they do not pile up in state store.
+func (e *OccurrenceExceptions) prune(items []OccurrenceException) []OccurrenceException {
+	now := e.clock.Now()
+	result := make([]OccurrenceException, 0, len(items))
+	for _, item := range items {
+		if !e.occurrenceExceptionsSetter.IsExceptionOver(item, now) {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
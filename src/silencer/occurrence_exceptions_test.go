@@ -0,0 +1,165 @@
+package silencer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOccurrenceExceptions(t *testing.T) {
+	now := time.Date(2021, 4, 7, 2, 30, 0, 0, time.UTC)
+	scheduledAt := time.Date(2021, 4, 7, 3, 0, 0, 0, time.UTC)
+	postponedTo := scheduledAt.Add(15 * time.Minute)
+
+	store := NewMemoryStateStore()
+
+	maintenance := YamlMaintenance{
+		ID:       "vacuum",
+		Matchers: []string{"alertname=vacuum"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+
+	silencer := newSilencerMock()
+	start := func() (*MaintenanceService, http.Handler) {
+		maintenanceService := NewMaintenanceService(
+			Instance{},
+			MustMaintenances(ParseMaintenances([]YamlMaintenance{maintenance})),
+			time.Hour,
+			0,
+			[]Target{NewTarget(DefaultTargetName, silencer)},
+			store,
+			NewMemoryHistory(ClockMock{now}),
+			ClockMock{now},
+			logrus.New(),
+		)
+
+		occurrenceExceptions := NewOccurrenceExceptions(store, maintenanceService, NewMemoryHistory(ClockMock{now}), ClockMock{now})
+		err := occurrenceExceptions.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = maintenanceService.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := chi.NewRouter()
+		NewMaintenanceAPIHandler(
+			maintenanceService,
+			BuildYamlMaintenanceIndex([]YamlMaintenance{maintenance}),
+			nil,
+			maintenanceService,
+			occurrenceExceptions,
+			ClockMock{now},
+		).Register(r)
+
+		return maintenanceService, r
+	}
+
+	maintenanceService, r := start()
+	assert.Equal(t, []time.Time{scheduledAt}, silencer.startTimes(), "pending silence")
+
+	w := serveJSON(r, http.MethodPost, "/maintenances/vacuum/exceptions", APIExceptionSpec{
+		ScheduledAt: scheduledAt,
+		Action:      ExceptionActionSkip,
+		Author:      "dba",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, silencer.startTimes(), "pending silence of skipped occurrence is deleted")
+
+	to := url.QueryEscape(postponedTo.Format(time.RFC3339))
+	w = serveJSON(r, http.MethodGet, "/maintenances/vacuum/occurrences?to="+to, nil)
+	occurrences := make([]APIOccurrence, 0)
+	err := json.Unmarshal(w.Body.Bytes(), &occurrences)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, occurrences, 1)
+	assert.Equal(t, ExceptionActionSkip, occurrences[0].Exception.Action)
+	assert.Equal(t, "dba", occurrences[0].Exception.Actor)
+
+	w = serveJSON(r, http.MethodPost, "/maintenances/vacuum/exceptions", APIExceptionSpec{
+		ScheduledAt: scheduledAt,
+		Action:      ExceptionActionPostpone,
+		StartAt:     &postponedTo,
+		Author:      "dba",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, []time.Time{postponedTo}, silencer.startTimes())
+	assert.Equal(t, postponedTo, maintenanceService.WatchedMaintenances()[0].Next.UTC())
+
+	w = serveJSON(r, http.MethodPost, "/maintenances/vacuum/exceptions", APIExceptionSpec{
+		ScheduledAt: scheduledAt.Add(30 * time.Minute),
+		Action:      ExceptionActionSkip,
+		Author:      "dba",
+	})
+	assert.Equal(t, http.StatusNotFound, w.Code, "occurrence is not scheduled")
+
+	w = serveJSON(r, http.MethodPost, "/maintenances/vacuum/exceptions", APIExceptionSpec{
+		ScheduledAt: scheduledAt.Add(-24 * time.Hour),
+		Action:      ExceptionActionSkip,
+		Author:      "dba",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "occurrence is over")
+
+	// exceptions survive restart
+	_ = maintenanceService.Stop(context.Background())
+	maintenanceService, r = start()
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+	assert.Equal(t, []time.Time{postponedTo}, silencer.startTimes())
+	assert.Len(t, maintenanceService.WatchedMaintenances()[0].Exceptions, 1)
+
+	w = serveJSON(r, http.MethodDelete, "/maintenances/vacuum/exceptions?author=dba", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "scheduledAt is required")
+
+	w = serveJSON(r, http.MethodDelete, "/maintenances/vacuum/exceptions?author=dba&scheduledAt=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	restored := url.QueryEscape(scheduledAt.Format(time.RFC3339))
+	w = serveJSON(r, http.MethodDelete, "/maintenances/vacuum/exceptions?scheduledAt="+restored, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "author is required")
+
+	w = serveJSON(r, http.MethodDelete, "/maintenances/vacuum/exceptions?author=dba&scheduledAt="+restored, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []time.Time{scheduledAt}, silencer.startTimes())
+	assert.Empty(t, maintenanceService.WatchedMaintenances()[0].Exceptions)
+
+	// exceptions, which are over, are dropped from store
+	w = serveJSON(r, http.MethodPost, "/maintenances/vacuum/exceptions", APIExceptionSpec{
+		ScheduledAt: scheduledAt,
+		Action:      ExceptionActionSkip,
+		Author:      "dba",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	_ = maintenanceService.Stop(context.Background())
+	now = scheduledAt.Add(24*time.Hour - 30*time.Minute)
+	maintenanceService, r = start()
+	assert.Empty(t, maintenanceService.WatchedMaintenances()[0].Exceptions)
+
+	w = serveJSON(r, http.MethodPost, "/maintenances/vacuum/exceptions", APIExceptionSpec{
+		ScheduledAt: scheduledAt.Add(24 * time.Hour),
+		Action:      ExceptionActionSkip,
+		Author:      "dba",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	stored := make([]OccurrenceException, 0)
+	_, err = store.Get(occurrenceExceptionsKey, &stored)
+	assert.NoError(t, err)
+	if assert.Len(t, stored, 1) {
+		assert.True(t, scheduledAt.Add(24*time.Hour).Equal(stored[0].ScheduledAt))
+	}
+}
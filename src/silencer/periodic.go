@@ -0,0 +1,56 @@
+package silencer
+
+import (
+	"context"
+	"time"
+)
+
+// periodicRunner runs function every interval until stopped. Zero interval disables it.
+type periodicRunner struct {
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newPeriodicRunner(interval time.Duration) *periodicRunner {
+	return &periodicRunner{
+		interval,
+		make(chan struct{}),
+		make(chan struct{}),
+	}
+}
+
+func (r *periodicRunner) start(f func()) {
+	if r.interval <= 0 {
+		close(r.done)
+		return
+	}
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				f()
+			}
+		}
+	}()
+}
+
+func (r *periodicRunner) Stop(ctx context.Context) error {
+	close(r.stop)
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
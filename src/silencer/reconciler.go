@@ -0,0 +1,44 @@
+package silencer
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type maintenanceReconciler interface {
+	Reconcile() error
+}
+
+type Reconciler struct {
+	*periodicRunner
+	maintenanceReconciler maintenanceReconciler
+	metrics               *Metrics
+	logger                logrus.FieldLogger
+}
+
+func NewReconciler(
+	maintenanceReconciler maintenanceReconciler,
+	interval time.Duration,
+	metrics *Metrics,
+	logger logrus.FieldLogger,
+) *Reconciler {
+	return &Reconciler{
+		newPeriodicRunner(interval),
+		maintenanceReconciler,
+		metrics,
+		logger,
+	}
+}
+
+// Start reconciles maintenances with Alertmanager every interval. Zero interval disables reconciliation.
+func (r *Reconciler) Start() {
+	r.start(func() {
+		start := time.Now()
+		err := r.maintenanceReconciler.Reconcile()
+		r.metrics.ObserveReconcile(time.Since(start), err)
+		if err != nil {
+			r.logger.WithError(err).Error("failed to reconcile silences")
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package silencer
+
+import (
+	"net/http"
+)
+
+type configReloader interface {
+	Reload() error
+}
+
+type ReloadHandler struct {
+	configReloader configReloader
+}
+
+func NewReloadHandler(
+	configReloader configReloader,
+) *ReloadHandler {
+	return &ReloadHandler{
+		configReloader,
+	}
+}
+
+func (h *ReloadHandler) Handle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.configReloader.Reload()
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package silencer
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/sirupsen/logrus"
+)
+
+type RetryPolicy struct {
+	// Timeout limits every single call
+	Timeout         time.Duration
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// MaxElapsed limits the whole call, backoffs included, so a single call does not take up the deadline
+	// of maintenance service operation. Call, which fails within it, is left to reconciliation.
+	MaxElapsed time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Timeout:         10 * time.Second,
+		MaxAttempts:     5,
+		InitialInterval: time.Second,
+		MaxInterval:     30 * time.Second,
+		MaxElapsed:      15 * time.Second,
+	}
+}
+
+// RetryingSilencer retries failed Alertmanager calls with exponential backoff and jitter.
+// Client errors (4xx) are never retried, they will not succeed anyway.
+type RetryingSilencer struct {
+	silencer silencer
+	policy   RetryPolicy
+	logger   logrus.FieldLogger
+}
+
+func NewRetryingSilencer(
+	silencer silencer,
+	policy RetryPolicy,
+	logger logrus.FieldLogger,
+) *RetryingSilencer {
+	return &RetryingSilencer{
+		silencer,
+		policy,
+		logger,
+	}
+}
+
+func (s *RetryingSilencer) Add(ctx context.Context, silence Silence) (ActiveSilenceID, error) {
+	var id ActiveSilenceID
+	err := s.retry(ctx, "post silence", func(ctx context.Context) error {
+		var err error
+		id, err = s.silencer.Add(ctx, silence)
+		return err
+	})
+
+	return id, err
+}
+
+func (s *RetryingSilencer) Delete(ctx context.Context, id ActiveSilenceID) error {
+	return s.retry(ctx, "delete silence", func(ctx context.Context) error {
+		return s.silencer.Delete(ctx, id)
+	})
+}
+
+func (s *RetryingSilencer) SetEnd(ctx context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error) {
+	var newID ActiveSilenceID
+	err := s.retry(ctx, "update silence", func(ctx context.Context) error {
+		var err error
+		newID, err = s.silencer.SetEnd(ctx, id, endsAt)
+		return err
+	})
+
+	return newID, err
+}
+
+func (s *RetryingSilencer) ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error) {
+	var activeSilences []ActiveSilence
+	err := s.retry(ctx, "get silences", func(ctx context.Context) error {
+		var err error
+		activeSilences, err = s.silencer.ActiveSilences(ctx, createdBy)
+		return err
+	})
+
+	return activeSilences, err
+}
+
+func (s *RetryingSilencer) retry(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+	if s.policy.MaxElapsed > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.policy.MaxElapsed)
+		defer cancel()
+	}
+
+	interval := s.policy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, s.policy.Timeout)
+		err := call(callCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if IsPermanentError(err) || attempt >= s.policy.MaxAttempts {
+			return errors.Wrapf(err, "failed to %s after %d attempts", operation, attempt)
+		}
+
+		delay := jitter(interval)
+		// attempt, which could not be made in time, is not waited for
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return errors.Wrapf(err, "failed to %s after %d attempts", operation, attempt)
+		}
+		s.logger.WithError(err).Warnf("failed to %s, retrying in %s", operation, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.Wrapf(err, "failed to %s", operation)
+		}
+
+		interval *= 2
+		if interval > s.policy.MaxInterval {
+			interval = s.policy.MaxInterval
+		}
+	}
+}
+
+// jitter spreads interval randomly within [interval/2, interval*3/2).
+func jitter(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	return interval/2 + time.Duration(rand.Int63n(int64(interval)))
+}
+
+// IsPermanentError reports whether Alertmanager rejected request, so retrying it is pointless.
+// Network errors and server errors are temporary.
+func IsPermanentError(err error) bool {
+	code, ok := statusCode(errors.Cause(err))
+	return ok && code >= 400 && code < 500
+}
+
+func statusCode(err error) (int, bool) {
+	switch e := err.(type) {
+	case *runtime.APIError:
+		return e.Code, true
+	case *silence.PostSilencesBadRequest:
+		return http.StatusBadRequest, true
+	case *silence.PostSilencesNotFound, *silence.GetSilenceNotFound:
+		return http.StatusNotFound, true
+	case *silence.DeleteSilenceInternalServerError,
+		*silence.GetSilencesInternalServerError,
+		*silence.GetSilenceInternalServerError:
+		return http.StatusInternalServerError, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,120 @@
+package silencer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryingSilencer_Add(t *testing.T) {
+	testCases := []struct {
+		name             string
+		errors           []error
+		expectedAttempts int
+		expectedErr      bool
+	}{
+		{
+			name:             "network errors are retried",
+			errors:           []error{errors.New("connection refused"), errors.New("connection refused")},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "server errors are retried",
+			errors:           []error{&runtime.APIError{Code: http.StatusServiceUnavailable}},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "client errors are not retried",
+			errors:           []error{&silence.PostSilencesBadRequest{}},
+			expectedAttempts: 1,
+			expectedErr:      true,
+		},
+		{
+			name: "attempts are limited",
+			errors: []error{
+				errors.New("connection refused"),
+				errors.New("connection refused"),
+				errors.New("connection refused"),
+			},
+			expectedAttempts: 3,
+			expectedErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failing := &failingSilencerMock{errors: tc.errors}
+			retryingSilencer := NewRetryingSilencer(
+				failing,
+				RetryPolicy{
+					Timeout:         time.Second,
+					MaxAttempts:     3,
+					InitialInterval: time.Millisecond,
+					MaxInterval:     time.Millisecond,
+				},
+				logrus.New(),
+			)
+
+			_, err := retryingSilencer.Add(context.Background(), Silence{})
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedAttempts, failing.attempts)
+		})
+	}
+}
+
+func TestRetryingSilencer_MaxElapsed(t *testing.T) {
+	failing := &failingSilencerMock{errors: []error{errors.New("connection refused"), errors.New("connection refused")}}
+	retryingSilencer := NewRetryingSilencer(
+		failing,
+		RetryPolicy{
+			Timeout:         time.Second,
+			MaxAttempts:     5,
+			InitialInterval: time.Second,
+			MaxInterval:     time.Second,
+			MaxElapsed:      100 * time.Millisecond,
+		},
+		logrus.New(),
+	)
+
+	startedAt := time.Now()
+	_, err := retryingSilencer.Add(context.Background(), Silence{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, failing.attempts, "backoff does not fit into max elapsed")
+	assert.Less(t, int64(time.Since(startedAt)), int64(time.Second))
+}
+
+// failingSilencerMock fails with given errors one by one and succeeds afterwards
+type failingSilencerMock struct {
+	errors   []error
+	attempts int
+}
+
+func (m *failingSilencerMock) Add(_ context.Context, _ Silence) (ActiveSilenceID, error) {
+	m.attempts++
+	if len(m.errors) > 0 {
+		err := m.errors[0]
+		m.errors = m.errors[1:]
+		return "", err
+	}
+
+	return "id", nil
+}
+
+func (m *failingSilencerMock) Delete(_ context.Context, _ ActiveSilenceID) error {
+	return nil
+}
+
+func (m *failingSilencerMock) SetEnd(_ context.Context, id ActiveSilenceID, _ time.Time) (ActiveSilenceID, error) {
+	return id, nil
+}
+
+func (m *failingSilencerMock) ActiveSilences(_ context.Context, _ string) ([]ActiveSilence, error) {
+	return nil, nil
+}
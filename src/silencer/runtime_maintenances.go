@@ -0,0 +1,241 @@
+package silencer
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const runtimeMaintenancesKey = "maintenances"
+
+var ErrMaintenanceNotFound = errors.New("maintenance not found")
+
+// InvalidMaintenanceError is returned for maintenance, which could not be applied
+type InvalidMaintenanceError struct {
+	err error
+}
+
+func (e InvalidMaintenanceError) Error() string {
+	return e.err.Error()
+}
+
+type routeChecker interface {
+	IsRouted(m Maintenance) bool
+}
+
+// RuntimeMaintenances are ad-hoc maintenances created via API. They are persisted to state store,
+// so they survive restarts.
+type RuntimeMaintenances struct {
+	store             StateStore
+	maintenanceSource maintenanceSource
+	routeChecker      routeChecker
+	history           historyRecorder
+	logger            logrus.FieldLogger
+
+	items []YamlMaintenance
+	// disabled are persisted maintenances, which could not be applied on load, e.g. routed to removed alertmanager.
+	// They are kept in state store, so they are back once config allows them.
+	disabled []YamlMaintenance
+	mux      sync.Mutex
+}
+
+func NewRuntimeMaintenances(
+	store StateStore,
+	maintenanceSource maintenanceSource,
+	routeChecker routeChecker,
+	history historyRecorder,
+	logger logrus.FieldLogger,
+) *RuntimeMaintenances {
+	return &RuntimeMaintenances{
+		store:             store,
+		maintenanceSource: maintenanceSource,
+		routeChecker:      routeChecker,
+		history:           history,
+		logger:            logger,
+	}
+}
+
+// Load reads persisted maintenances. Maintenances, which are not valid anymore, are disabled and logged,
+// so they do not prevent start.
+func (r *RuntimeMaintenances) Load() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	stored := make([]YamlMaintenance, 0)
+	_, err := r.store.Get(runtimeMaintenancesKey, &stored)
+	if err != nil {
+		return errors.Wrap(err, "failed to load runtime maintenances")
+	}
+
+	items := make([]YamlMaintenance, 0, len(stored))
+	r.disabled = make([]YamlMaintenance, 0)
+	for _, m := range stored {
+		err := r.validate(m)
+		if err != nil {
+			r.logger.WithError(err).Warnf("runtime maintenance %s is disabled", m.ID)
+			r.disabled = append(r.disabled, m)
+			continue
+		}
+		items = append(items, m)
+	}
+
+	return r.apply(items)
+}
+
+// Create adds maintenance, id is generated unless it is set.
+func (r *RuntimeMaintenances) Create(m YamlMaintenance) (YamlMaintenance, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if m.ID == "" {
+		m.ID = uuid.NewV4().String()
+	}
+
+	if r.index(m.ID) >= 0 || r.isDisabled(m.ID) {
+		return YamlMaintenance{}, InvalidMaintenanceError{errors.Errorf("duplicate maintenance id %s", m.ID)}
+	}
+
+	items := append(append(make([]YamlMaintenance, 0, len(r.items)+1), r.items...), m)
+	err := r.save(items)
+	if err != nil {
+		return YamlMaintenance{}, err
+	}
+
+	r.record(HistoryActionCreate, m.Identity(), m.Author)
+
+	return m, nil
+}
+
+// Update replaces maintenance keeping its id, so its silences are kept when content allows.
+func (r *RuntimeMaintenances) Update(id string, m YamlMaintenance) (YamlMaintenance, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return YamlMaintenance{}, ErrMaintenanceNotFound
+	}
+
+	m.ID = id
+	items := append(make([]YamlMaintenance, 0, len(r.items)), r.items...)
+	items[i] = m
+
+	err := r.save(items)
+	if err != nil {
+		return YamlMaintenance{}, err
+	}
+
+	r.record(HistoryActionUpdate, m.Identity(), m.Author)
+
+	return m, nil
+}
+
+func (r *RuntimeMaintenances) Delete(id string, actor string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return ErrMaintenanceNotFound
+	}
+
+	deleted := r.items[i]
+	items := append(make([]YamlMaintenance, 0, len(r.items)), r.items[:i]...)
+	items = append(items, r.items[i+1:]...)
+
+	err := r.save(items)
+	if err != nil {
+		return err
+	}
+
+	r.record(HistoryActionDelete, deleted.Identity(), actor)
+
+	return nil
+}
+
+func (r *RuntimeMaintenances) record(action string, hash MaintenanceHash, actor string) {
+	r.history.Record(HistoryEntry{
+		Event:       HistoryEventManual,
+		Action:      action,
+		Maintenance: hash,
+		Actor:       actor,
+	})
+}
+
+func (r *RuntimeMaintenances) index(id string) int {
+	for i, m := range r.items {
+		if m.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (r *RuntimeMaintenances) isDisabled(id string) bool {
+	for _, m := range r.disabled {
+		if m.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// save applies maintenances and persists them along with disabled ones, previous ones are restored
+// when persisting fails.
+func (r *RuntimeMaintenances) save(items []YamlMaintenance) error {
+	previous := r.items
+
+	err := r.apply(items)
+	if err != nil {
+		return err
+	}
+
+	err = r.store.Put(runtimeMaintenancesKey, append(append(make([]YamlMaintenance, 0), items...), r.disabled...))
+	if err != nil {
+		_ = r.apply(previous)
+		return err
+	}
+
+	return nil
+}
+
+// validate checks maintenance on its own: it is parsed and routed to known alertmanager.
+func (r *RuntimeMaintenances) validate(item YamlMaintenance) error {
+	m, err := ParseMaintenance(item)
+	if err != nil {
+		return InvalidMaintenanceError{err}
+	}
+
+	if !r.routeChecker.IsRouted(m) {
+		return InvalidMaintenanceError{errors.Errorf("unknown alertmanager %s", m.Alertmanager)}
+	}
+
+	return nil
+}
+
+func (r *RuntimeMaintenances) apply(items []YamlMaintenance) error {
+	for _, item := range items {
+		err := r.validate(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	maintenances, err := ParseMaintenances(items)
+	if err != nil {
+		return InvalidMaintenanceError{err}
+	}
+
+	err = r.maintenanceSource.Set(items, maintenances)
+	if err != nil {
+		return InvalidMaintenanceError{err}
+	}
+
+	r.items = items
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package silencer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeMaintenances(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(20 * time.Second)
+
+	dir, err := ioutil.TempDir("", "silencer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := NewFileStateStore(dir)
+
+	configured := YamlMaintenance{
+		ID:       "configured",
+		Matchers: []string{"alertname=configured"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+	}
+
+	silencer := newSilencerMock()
+	start := func() (*MaintenanceService, http.Handler) {
+		maintenanceService := NewMaintenanceService(
+			Instance{},
+			nil,
+			0,
+			0,
+			[]Target{NewTarget(DefaultTargetName, silencer)},
+			store,
+			NewMemoryHistory(ClockMock{now}),
+			ClockMock{now},
+			logrus.New(),
+		)
+
+		yamlMaintenanceIndex := NewReloadableYamlMaintenanceIndex(YamlMaintenanceIndex{})
+		sources := NewMaintenanceSources(maintenanceService, yamlMaintenanceIndex, MaintenanceSourceFile, MaintenanceSourceAPI)
+		err := sources.Source(MaintenanceSourceFile).Set(
+			[]YamlMaintenance{configured},
+			MustMaintenances(ParseMaintenances([]YamlMaintenance{configured})),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		runtimeMaintenances := NewRuntimeMaintenances(
+			store,
+			sources.Source(MaintenanceSourceAPI),
+			maintenanceService,
+			NewMemoryHistory(ClockMock{now}),
+			logrus.New(),
+		)
+		err = runtimeMaintenances.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = maintenanceService.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := chi.NewRouter()
+		NewMaintenanceAPIHandler(
+			maintenanceService,
+			yamlMaintenanceIndex,
+			runtimeMaintenances,
+			maintenanceService,
+			nil,
+			ClockMock{now},
+		).Register(r)
+
+		return maintenanceService, r
+	}
+
+	maintenanceService, r := start()
+
+	w := serveJSON(r, http.MethodPost, "/maintenances", APIMaintenanceSpec{
+		Matchers: []string{"alertname=adhoc"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+		Author:   "on-call",
+		Comment:  "network works",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	created := APIMaintenance{}
+	err = json.Unmarshal(w.Body.Bytes(), &created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, MaintenanceSourceAPI, created.Source)
+	assert.Equal(t, "on-call", created.Author)
+	assert.True(t, created.IsActive)
+	assert.Len(t, silencer.comments(), 1)
+
+	w = serveJSON(r, http.MethodPost, "/maintenances", APIMaintenanceSpec{
+		Matchers: []string{"alertname=adhoc"},
+		Schedule: "* * * * *",
+		Duration: "50s",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "author is required")
+
+	w = serveJSON(r, http.MethodPut, "/maintenances/configured", APIMaintenanceSpec{
+		Matchers: []string{"alertname=configured"},
+		Schedule: "0 4 * * *",
+		Duration: "1h",
+		Author:   "on-call",
+	})
+	assert.Equal(t, http.StatusConflict, w.Code, "maintenances of config file are read-only")
+
+	// runtime maintenances survive restart
+	_ = maintenanceService.Stop(context.Background())
+	maintenanceService, r = start()
+	defer func() {
+		_ = maintenanceService.Stop(context.Background())
+	}()
+	assert.Len(t, maintenanceService.WatchedMaintenances(), 2)
+
+	w = serveJSON(r, http.MethodPut, "/maintenances/"+created.ID, APIMaintenanceSpec{
+		Matchers: []string{"alertname=adhoc"},
+		Schedule: "* * * * *",
+		Duration: "55s",
+		Author:   "on-call",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveJSON(r, http.MethodDelete, "/maintenances/"+created.ID, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "author is required")
+
+	w = serveJSON(r, http.MethodDelete, "/maintenances/"+created.ID+"?author=on-call", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, silencer.comments())
+	assert.Len(t, maintenanceService.WatchedMaintenances(), 1)
+}
+
+func TestRuntimeMaintenances_LoadDisablesInvalid(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 30, 0, 0, time.UTC)
+	store := NewMemoryStateStore()
+	routed := YamlMaintenance{ID: "routed", Matchers: []string{"alertname=routed"}, Schedule: "0 3 * * *", Duration: "1h"}
+	unrouted := YamlMaintenance{
+		ID:           "unrouted",
+		Matchers:     []string{"alertname=unrouted"},
+		Schedule:     "0 3 * * *",
+		Duration:     "1h",
+		Alertmanager: "removed",
+	}
+	err := store.Put(runtimeMaintenancesKey, []YamlMaintenance{routed, unrouted})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maintenanceService := NewMaintenanceService(
+		Instance{},
+		nil,
+		0,
+		0,
+		[]Target{NewTarget(DefaultTargetName, newSilencerMock())},
+		store,
+		NewMemoryHistory(ClockMock{now}),
+		ClockMock{now},
+		logrus.New(),
+	)
+	sources := NewMaintenanceSources(
+		maintenanceService,
+		NewReloadableYamlMaintenanceIndex(YamlMaintenanceIndex{}),
+		MaintenanceSourceAPI,
+	)
+	runtimeMaintenances := NewRuntimeMaintenances(
+		store,
+		sources.Source(MaintenanceSourceAPI),
+		maintenanceService,
+		NewMemoryHistory(ClockMock{now}),
+		logrus.New(),
+	)
+
+	err = runtimeMaintenances.Load()
+	assert.NoError(t, err, "maintenance of removed alertmanager does not prevent start")
+	assert.Len(t, maintenanceService.WatchedMaintenances(), 1)
+
+	_, err = runtimeMaintenances.Create(unrouted)
+	assert.Error(t, err, "id of disabled maintenance is taken")
+
+	_, err = runtimeMaintenances.Update("routed", YamlMaintenance{
+		Matchers: []string{"alertname=routed"},
+		Schedule: "0 4 * * *",
+		Duration: "1h",
+	})
+	assert.NoError(t, err)
+
+	stored := make([]YamlMaintenance, 0)
+	_, err = store.Get(runtimeMaintenancesKey, &stored)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"routed", "unrouted"}, []string{stored[0].ID, stored[1].ID}, "disabled maintenance is kept")
+}
+
+func serveJSON(h http.Handler, method, url string, body interface{}) *httptest.ResponseRecorder {
+	content, _ := json.Marshal(body)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, bytes.NewReader(content)))
+
+	return w
+}
@@ -0,0 +1,238 @@
+package silencer
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"github.com/teambition/rrule-go"
+)
+
+// ZonedSchedule evaluates cron spec against the wall clock of Location.
+//
+// Daylight saving time transitions are handled as follows:
+//   - start time within a skipped hour is moved forward to the end of the gap,
+//     e.g. 02:30 becomes 03:00 when clocks jump from 02:00 to 03:00;
+//   - start time within a repeated hour fires once, at its first occurrence;
+//   - maintenance duration is elapsed time, a window crossing transition lasts exactly its duration.
+type ZonedSchedule struct {
+	Spec     *cron.SpecSchedule
+	Location *time.Location
+}
+
+func (s ZonedSchedule) Next(t time.Time) time.Time {
+	return nextByWallClock(t, s.Location, s.Spec.Next)
+}
+
+// RecurrenceSchedule fires on occurrences of RFC 5545 recurrence rule, which are evaluated against the wall clock
+// of Location, so daylight saving time transitions are handled the same way ZonedSchedule does.
+// Rule is anchored at its DTSTART, rule bounded by COUNT or UNTIL finishes after its last occurrence.
+type RecurrenceSchedule struct {
+	// Rule is evaluated in UTC, which stands for the wall clock of Location
+	Rule     *rrule.RRule
+	Location *time.Location
+}
+
+func (s RecurrenceSchedule) Next(t time.Time) time.Time {
+	return nextByWallClock(t, s.Location, func(wall time.Time) time.Time {
+		return s.Rule.After(wall, false)
+	})
+}
+
+// OneOffSchedule fires exactly once, at Start.
+type OneOffSchedule struct {
+	Start time.Time
+}
+
+func (s OneOffSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.Start) {
+		return s.Start
+	}
+
+	return time.Time{}
+}
+
+// RecurrenceSetSchedule fires on occurrences of iCalendar recurrence set: recurrence rule of event
+// along with added and excluded dates.
+type RecurrenceSetSchedule struct {
+	set *rrule.Set
+	// mux guards set, which sorts its dates on every iteration
+	mux *sync.Mutex
+}
+
+func NewRecurrenceSetSchedule(set *rrule.Set) RecurrenceSetSchedule {
+	return RecurrenceSetSchedule{set, &sync.Mutex{}}
+}
+
+func (s RecurrenceSetSchedule) Next(t time.Time) time.Time {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.set.After(t, false)
+}
+
+// ExceptionSchedule is schedule with single occurrences skipped or moved to other time.
+type ExceptionSchedule struct {
+	Schedule cron.Schedule
+	// Skipped are unix times of scheduled starts, which do not happen
+	Skipped map[int64]bool
+	// Added are starts of moved occurrences in ascending order
+	Added []time.Time
+}
+
+func (s ExceptionSchedule) Next(t time.Time) time.Time {
+	next := s.Schedule.Next(t)
+	for !next.IsZero() && s.Skipped[next.Unix()] {
+		next = s.Schedule.Next(next)
+	}
+
+	for _, added := range s.Added {
+		if !added.After(t) {
+			continue
+		}
+
+		if next.IsZero() || added.Before(next) {
+			return added
+		}
+		break
+	}
+
+	return next
+}
+
+// baseSchedule returns schedule without exceptions.
+func baseSchedule(schedule cron.Schedule) cron.Schedule {
+	if s, ok := schedule.(ExceptionSchedule); ok {
+		return s.Schedule
+	}
+
+	return schedule
+}
+
+func parseSchedule(schedule string, location *time.Location) (cron.Schedule, error) {
+	parsed, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, ok := parsed.(*cron.SpecSchedule)
+	if !ok {
+		return parsed, nil
+	}
+
+	if spec.Location != time.Local {
+		if location != time.Local && location.String() != spec.Location.String() {
+			return nil, errors.Errorf("schedule time zone %s conflicts with timezone %s", spec.Location, location)
+		}
+		location = spec.Location
+	}
+
+	zonedSpec := *spec
+	zonedSpec.Location = time.UTC
+
+	return ZonedSchedule{&zonedSpec, location}, nil
+}
+
+// parseRecurrence parses recurrence rule, e.g. DTSTART=20210102T030000;FREQ=WEEKLY;INTERVAL=2;BYDAY=SA.
+// DTSTART is required: it is the anchor of intervals and the time of day of occurrences. DTSTART and UNTIL
+// are wall clock in location unless they end with Z.
+func parseRecurrence(recurrence string, location *time.Location) (cron.Schedule, error) {
+	// floating times are parsed in zone of their own, so they are taken as written even within skipped hour
+	floating := time.FixedZone("floating", 0)
+	options, err := parseRecurrenceOptions(recurrence, floating)
+	if err != nil {
+		return nil, err
+	}
+
+	toWallClock := func(t time.Time) time.Time {
+		if t.Location() != floating {
+			t = t.In(location)
+		}
+		return wallClock(t)
+	}
+	options.Dtstart = toWallClock(options.Dtstart)
+	if !options.Until.IsZero() {
+		options.Until = toWallClock(options.Until)
+	}
+
+	rule, err := rrule.NewRRule(*options)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid recurrence")
+	}
+
+	return RecurrenceSchedule{rule, location}, nil
+}
+
+// parseRecurrenceOptions parses recurrence rule with DTSTART, times without zone are in location.
+func parseRecurrenceOptions(recurrence string, location *time.Location) (*rrule.ROption, error) {
+	options, err := rrule.StrToROptionInLocation(strings.TrimPrefix(recurrence, "RRULE:"), location)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid recurrence")
+	}
+
+	if options.Dtstart.IsZero() {
+		return nil, errors.New("recurrence requires DTSTART")
+	}
+
+	// occurrences are iterated from DTSTART on every call, frequent ones are left to cron schedule
+	if options.Freq > rrule.DAILY {
+		return nil, errors.Errorf("recurrence frequency %s is not supported, use schedule", options.Freq)
+	}
+
+	return options, nil
+}
+
+// nextByWallClock returns the first instant after t, which wall clock in location is returned by next.
+// next is given wall clock represented as UTC time.
+func nextByWallClock(t time.Time, location *time.Location, next func(wall time.Time) time.Time) time.Time {
+	wall := wallClock(t.In(location))
+	for {
+		wall = next(wall)
+		if wall.IsZero() {
+			return wall
+		}
+
+		result := fromWallClock(wall, location)
+		if result.After(t) {
+			return result
+		}
+	}
+}
+
+// wallClock represents wall clock of t as UTC time.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWallClock returns the earliest instant, which has given wall clock in location.
+// When wall clock does not exist in location, the end of the gap is returned.
+func fromWallClock(wall time.Time, location *time.Location) time.Time {
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(location).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(location).Zone()
+
+	earliest := wall.Add(-time.Duration(offsetBefore) * time.Second)
+	latest := wall.Add(-time.Duration(offsetAfter) * time.Second)
+	if latest.Before(earliest) {
+		earliest, latest = latest, earliest
+	}
+
+	for _, candidate := range []time.Time{earliest, latest} {
+		if wallClock(candidate.In(location)).Equal(wall) {
+			return candidate.In(location)
+		}
+	}
+
+	_, offset := earliest.In(location).Zone()
+	for latest.Sub(earliest) > time.Nanosecond {
+		middle := earliest.Add(latest.Sub(earliest) / 2)
+		if _, o := middle.In(location).Zone(); o == offset {
+			earliest = middle
+		} else {
+			latest = middle
+		}
+	}
+
+	return latest.In(location)
+}
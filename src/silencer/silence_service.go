@@ -68,9 +68,34 @@ func (s *SilenceService) Delete(ctx context.Context, id ActiveSilenceID) error {
 	return nil
 }
 
+// SetEnd changes end of silence keeping the rest of it. Alertmanager updates active silence in place,
+// unless it has to replace it, id of the resulting silence is returned.
+func (s *SilenceService) SetEnd(ctx context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error) {
+	getOk, err := s.silenceClient.GetSilence(
+		silence.NewGetSilenceParams().
+			WithContext(ctx).
+			WithSilenceID(id.strfmtUUID()),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	end := strfmt.DateTime(endsAt.UTC())
+	postableSilence := &models.PostableSilence{
+		ID:      string(id),
+		Silence: getOk.GetPayload().Silence,
+	}
+	postableSilence.EndsAt = &end
+
+	return s.add(ctx, postableSilence)
+}
+
 type ActiveSilence struct {
-	ID      ActiveSilenceID
-	Comment string
+	ID       ActiveSilenceID
+	Comment  string
+	Matchers models.Matchers
+	StartsAt time.Time
+	EndsAt   time.Time
 }
 
 func (s *SilenceService) ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error) {
@@ -95,6 +120,10 @@ func (s *SilenceService) ActiveSilences(ctx context.Context, createdBy string) (
 			continue
 		}
 
+		if gettableSilence.StartsAt == nil || gettableSilence.EndsAt == nil {
+			continue
+		}
+
 		if !CreatedBy(gettableSilence, createdBy) {
 			continue
 		}
@@ -102,6 +131,9 @@ func (s *SilenceService) ActiveSilences(ctx context.Context, createdBy string) (
 		activeSilences = append(activeSilences, ActiveSilence{
 			ActiveSilenceID(*gettableSilence.ID),
 			*gettableSilence.Comment,
+			gettableSilence.Matchers,
+			time.Time(*gettableSilence.StartsAt),
+			time.Time(*gettableSilence.EndsAt),
 		})
 	}
 
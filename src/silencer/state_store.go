@@ -0,0 +1,213 @@
+package silencer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	StateStoreFile = "file"
+	StateStoreBolt = "bolt"
+)
+
+// StateStore keeps state of silencer across restarts, values are stored as JSON under keys.
+type StateStore interface {
+	// Get decodes value of key into value, false is returned when there is none
+	Get(key string, value interface{}) (bool, error)
+	Put(key string, value interface{}) error
+	Close() error
+}
+
+// NewStateStore opens store of kind in dir.
+func NewStateStore(kind string, dir string) (StateStore, error) {
+	switch kind {
+	case StateStoreFile:
+		return NewFileStateStore(dir), nil
+	case StateStoreBolt:
+		return NewBoltStateStore(filepath.Join(dir, "state.db"))
+	default:
+		return nil, errors.Errorf("unknown state store %q", kind)
+	}
+}
+
+// FileStateStore keeps every key in its own file of dir.
+type FileStateStore struct {
+	dir string
+	mux sync.Mutex
+}
+
+func NewFileStateStore(dir string) *FileStateStore {
+	return &FileStateStore{
+		dir: dir,
+	}
+}
+
+func (s *FileStateStore) Get(key string, value interface{}) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	content, err := ioutil.ReadFile(s.file(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(content, value)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode %s", s.file(key))
+	}
+
+	return true, nil
+}
+
+// Put replaces file of key atomically.
+func (s *FileStateStore) Put(key string, value interface{}) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	file := s.file(key)
+	tmp := file + ".tmp"
+	err = ioutil.WriteFile(tmp, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+func (s *FileStateStore) Close() error {
+	return nil
+}
+
+func (s *FileStateStore) file(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+var boltStateBucket = []byte("state")
+
+// BoltStateStore keeps keys in a single BoltDB file.
+type BoltStateStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStateStore(file string) (*BoltStateStore, error) {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", file)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltStateBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &BoltStateStore{db}, nil
+}
+
+func (s *BoltStateStore) Get(key string, value interface{}) (bool, error) {
+	var content []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// value is valid only within transaction
+		content = append(content, tx.Bucket(boltStateBucket).Get([]byte(key))...)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if content == nil {
+		return false, nil
+	}
+
+	err = json.Unmarshal(content, value)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode %s", key)
+	}
+
+	return true, nil
+}
+
+func (s *BoltStateStore) Put(key string, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltStateBucket).Put([]byte(key), content)
+	})
+}
+
+func (s *BoltStateStore) Close() error {
+	return s.db.Close()
+}
+
+// MemoryStateStore keeps state in memory, it does not survive restarts.
+type MemoryStateStore struct {
+	items map[string][]byte
+	mux   sync.Mutex
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		items: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStateStore) Get(key string, value interface{}) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	content, ok := s.items[key]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(content, value)
+}
+
+func (s *MemoryStateStore) Put(key string, value interface{}) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.items[key] = content
+	return nil
+}
+
+func (s *MemoryStateStore) Close() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package silencer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silencer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	boltStore, err := NewBoltStateStore(filepath.Join(dir, "bolt", "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer boltStore.Close()
+
+	testCases := []struct {
+		name  string
+		store StateStore
+	}{
+		{name: "file", store: NewFileStateStore(filepath.Join(dir, "file"))},
+		{name: "bolt", store: boltStore},
+		{name: "memory", store: NewMemoryStateStore()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value := map[string]string{}
+			ok, err := tc.store.Get("target:default/alertmanager:9093", &value)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			err = tc.store.Put("target:default/alertmanager:9093", map[string]string{"key": "value"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err = tc.store.Get("target:default/alertmanager:9093", &value)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, map[string]string{"key": "value"}, value)
+		})
+	}
+}
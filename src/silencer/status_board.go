@@ -2,6 +2,8 @@ package silencer
 
 import (
 	"bytes"
+	"encoding/json"
+	"sort"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -9,39 +11,116 @@ import (
 
 type RenderableMaintenance struct {
 	Maintenance YamlMaintenance `yaml:"maintenance"`
-	Next        time.Time       `yaml:"next"`
-	IsActive    bool            `yaml:"isActive"`
+	Next        time.Time       `yaml:"next,omitempty"`
+	// Source is shown only for maintenances created via API
+	Source    string `yaml:"source,omitempty"`
+	IsActive  bool   `yaml:"isActive"`
+	Status    string `yaml:"status,omitempty"`
+	LastError string `yaml:"lastError,omitempty"`
+	// Override is window started, ended or extended manually
+	Override *RenderableOverride `yaml:"override,omitempty"`
+	// Exceptions are skipped and postponed occurrences
+	Exceptions []RenderableException `yaml:"exceptions,omitempty"`
+	// Targets are shown only when maintenance is silenced in several targets
+	Targets []RenderableTarget `yaml:"targets,omitempty"`
 }
 
+type RenderableOverride struct {
+	Action  string    `yaml:"action"`
+	Actor   string    `yaml:"actor"`
+	At      time.Time `yaml:"at"`
+	StartAt time.Time `yaml:"startAt"`
+	EndAt   time.Time `yaml:"endAt"`
+}
+
+type RenderableException struct {
+	Action      string    `yaml:"action"`
+	ScheduledAt time.Time `yaml:"scheduledAt"`
+	StartAt     time.Time `yaml:"startAt,omitempty"`
+	Actor       string    `yaml:"actor"`
+}
+
+type RenderableTarget struct {
+	Name      string `yaml:"name"`
+	IsActive  bool   `yaml:"isActive"`
+	LastError string `yaml:"lastError,omitempty"`
+}
+
+type RenderableAlertmanagers struct {
+	Alertmanagers []AlertmanagerHealth `yaml:"alertmanagers"`
+}
+
+// JSONStatusBoard is status board rendered as single JSON document, maintenances are the ones of API
+type JSONStatusBoard struct {
+	Alertmanagers []AlertmanagerHealth `json:"alertmanagers"`
+	Maintenances  []APIMaintenance     `json:"maintenances"`
+}
+
+const (
+	oneOffStatusUpcoming = "upcoming"
+	oneOffStatusActive   = "active"
+	oneOffStatusFinished = "finished"
+)
+
 type watchedMaintenanceStorage interface {
 	WatchedMaintenances() []WatchedMaintenance
 }
 
+type yamlMaintenanceIndex interface {
+	Get(hash MaintenanceHash) YamlMaintenance
+}
+
+type alertmanagersHealthStorage interface {
+	AlertmanagersHealth() []AlertmanagerHealth
+}
+
 type StatusBoard struct {
-	watchedMaintenanceStorage watchedMaintenanceStorage
-	yamlMaintenanceIndex      YamlMaintenanceIndex
+	watchedMaintenanceStorage  watchedMaintenanceStorage
+	yamlMaintenanceIndex       yamlMaintenanceIndex
+	alertmanagersHealthStorage alertmanagersHealthStorage
+	clock                      clock
 }
 
 func NewStatusBoard(
 	watchedMaintenanceStorage watchedMaintenanceStorage,
-	yamlMaintenanceIndex YamlMaintenanceIndex,
+	yamlMaintenanceIndex yamlMaintenanceIndex,
+	alertmanagersHealthStorage alertmanagersHealthStorage,
+	clock clock,
 ) *StatusBoard {
 	return &StatusBoard{
 		watchedMaintenanceStorage,
 		yamlMaintenanceIndex,
+		alertmanagersHealthStorage,
+		clock,
 	}
 }
 
+// Render writes health of Alertmanagers followed by maintenances as yaml documents.
 func (b *StatusBoard) Render() ([]byte, error) {
 	buf := bytes.Buffer{}
 	yamlEncoder := yaml.NewEncoder(&buf)
 
+	alertmanagersHealth := b.alertmanagersHealthStorage.AlertmanagersHealth()
+	if len(alertmanagersHealth) > 0 {
+		err := yamlEncoder.Encode(RenderableAlertmanagers{alertmanagersHealth})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	maintenances := b.watchedMaintenanceStorage.WatchedMaintenances()
+	groupByAlertmanager(maintenances)
 	for _, m := range maintenances {
 		err := yamlEncoder.Encode(RenderableMaintenance{
-			Maintenance: b.yamlMaintenanceIndex[m.Maintenance.Hash],
+			Maintenance: b.yamlMaintenanceIndex.Get(m.Maintenance.Hash),
 			Next:        m.Next,
+			Source:      renderableSource(m.Maintenance.Source),
 			IsActive:    m.IsActive,
+			Status:      oneOffStatus(m),
+			LastError:   errorString(m.LastError),
+			Override:    renderableOverride(m.Override),
+			Exceptions:  renderableExceptions(m.Exceptions),
+			Targets:     renderableTargets(m.Targets),
 		})
 		if err != nil {
 			return nil, err
@@ -50,3 +129,117 @@ func (b *StatusBoard) Render() ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// RenderJSON writes health of Alertmanagers and maintenances as single JSON document.
+func (b *StatusBoard) RenderJSON() ([]byte, error) {
+	maintenances := b.watchedMaintenanceStorage.WatchedMaintenances()
+	groupByAlertmanager(maintenances)
+
+	board := JSONStatusBoard{
+		Alertmanagers: b.alertmanagersHealthStorage.AlertmanagersHealth(),
+		Maintenances:  make([]APIMaintenance, len(maintenances)),
+	}
+	for i, m := range maintenances {
+		board.Maintenances[i] = NewAPIMaintenance(m, b.yamlMaintenanceIndex.Get(m.Maintenance.Hash))
+	}
+
+	if board.Alertmanagers == nil {
+		board.Alertmanagers = make([]AlertmanagerHealth, 0)
+	}
+
+	return json.Marshal(board)
+}
+
+// oneOffStatus describes one-off maintenance progress, it is empty for recurring ones.
+func oneOffStatus(m WatchedMaintenance) string {
+	switch {
+	case !m.Maintenance.IsOneOff():
+		return ""
+	case m.IsActive:
+		return oneOffStatusActive
+	case m.IsFinished:
+		return oneOffStatusFinished
+	default:
+		return oneOffStatusUpcoming
+	}
+}
+
+// groupByAlertmanager orders maintenances by alertmanager they are silenced in, default one goes first.
+func groupByAlertmanager(maintenances []WatchedMaintenance) {
+	sort.SliceStable(maintenances, func(i, j int) bool {
+		a, b := maintenances[i].Maintenance.Alertmanager, maintenances[j].Maintenance.Alertmanager
+		if a == b {
+			return false
+		}
+		if a == DefaultTargetName || b == DefaultTargetName {
+			return a == DefaultTargetName
+		}
+
+		return a < b
+	})
+}
+
+func renderableSource(source string) string {
+	if source == MaintenanceSourceFile {
+		return ""
+	}
+
+	return source
+}
+
+func renderableOverride(o *WindowOverride) *RenderableOverride {
+	if o == nil {
+		return nil
+	}
+
+	return &RenderableOverride{
+		Action:  o.Action,
+		Actor:   o.Actor,
+		At:      o.At,
+		StartAt: o.Window.StartAt,
+		EndAt:   o.Window.EndAt,
+	}
+}
+
+func renderableExceptions(exceptions []OccurrenceException) []RenderableException {
+	if len(exceptions) == 0 {
+		return nil
+	}
+
+	result := make([]RenderableException, len(exceptions))
+	for i, e := range exceptions {
+		result[i] = RenderableException{
+			Action:      e.Action,
+			ScheduledAt: e.ScheduledAt,
+			StartAt:     e.StartAt,
+			Actor:       e.Actor,
+		}
+	}
+
+	return result
+}
+
+func renderableTargets(targets []WatchedTarget) []RenderableTarget {
+	if len(targets) < 2 {
+		return nil
+	}
+
+	result := make([]RenderableTarget, len(targets))
+	for i, t := range targets {
+		result[i] = RenderableTarget{
+			Name:      t.Name,
+			IsActive:  t.IsActive,
+			LastError: errorString(t.LastError),
+		}
+	}
+
+	return result
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
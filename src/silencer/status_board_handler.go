@@ -1,9 +1,35 @@
 package silencer
 
 import (
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// Formats of status board
+const (
+	StatusBoardFormatYAML = "yaml"
+	StatusBoardFormatJSON = "json"
+	StatusBoardFormatHTML = "html"
+)
+
+var statusBoardContentTypes = map[string]string{
+	StatusBoardFormatYAML: "application/yaml; charset=utf-8",
+	StatusBoardFormatJSON: "application/json; charset=utf-8",
+	StatusBoardFormatHTML: "text/html; charset=utf-8",
+}
+
+// statusBoardMediaTypes map media types of Accept header to formats
+var statusBoardMediaTypes = map[string]string{
+	"application/yaml":   StatusBoardFormatYAML,
+	"application/x-yaml": StatusBoardFormatYAML,
+	"text/yaml":          StatusBoardFormatYAML,
+	"text/x-yaml":        StatusBoardFormatYAML,
+	"application/json":   StatusBoardFormatJSON,
+	"text/html":          StatusBoardFormatHTML,
+}
+
 type StatusBoardHandler struct {
 	statusBoard *StatusBoard
 }
@@ -16,14 +42,65 @@ func NewStatusBoardHandler(
 	}
 }
 
+// Handle renders status board in format of format query parameter or Accept header, YAML is the default.
 func (h *StatusBoardHandler) Handle() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		statusBoard, err := h.statusBoard.Render()
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = negotiateStatusBoardFormat(r.Header.Get("Accept"))
+		}
+
+		var statusBoard []byte
+		var err error
+		switch format {
+		case StatusBoardFormatYAML:
+			statusBoard, err = h.statusBoard.Render()
+		case StatusBoardFormatJSON:
+			statusBoard, err = h.statusBoard.RenderJSON()
+		case StatusBoardFormatHTML:
+			statusBoard, err = h.statusBoard.RenderHTML()
+		default:
+			http.Error(w, "unknown format "+strconv.Quote(format), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, http.StatusText(500), 500)
 			return
 		}
 
+		w.Header().Set("Content-Type", statusBoardContentTypes[format])
+		w.Header().Add("Vary", "Accept")
 		_, _ = w.Write(statusBoard)
 	}
 }
+
+// negotiateStatusBoardFormat picks format of the most preferred media type of accept, the first one wins a tie.
+// YAML is picked when none of media types is supported.
+func negotiateStatusBoardFormat(accept string) string {
+	format, quality := StatusBoardFormatYAML, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		f, ok := statusBoardMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		if q > quality {
+			format, quality = f, q
+		}
+	}
+
+	return format
+}
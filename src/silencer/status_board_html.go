@@ -0,0 +1,196 @@
+package silencer
+
+import (
+	"bytes"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var statusBoardTemplate = template.Must(template.New("status board").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Silencer</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+tr.active { background: #d4f4d4; font-weight: bold; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<p>Rendered at {{ .Now }}</p>
+{{ with .Alertmanagers }}
+<h2>Alertmanagers</h2>
+<table>
+<tr><th>Target</th><th>URL</th><th>Last success</th><th>Last error</th></tr>
+{{ range . }}
+<tr>
+<td>{{ .Target }}</td>
+<td>{{ .URL }}</td>
+<td>{{ if not .LastSuccess.IsZero }}{{ .LastSuccess.Format "2006-01-02T15:04:05Z07:00" }}{{ end }}</td>
+<td class="error">{{ .LastError }}</td>
+</tr>
+{{ end }}
+</table>
+{{ end }}
+<h2>Maintenances</h2>
+<table>
+<tr>
+<th>Maintenance</th><th>Matchers</th><th>Schedule</th><th>Status</th><th>Remaining</th><th>Next start</th>
+<th>Silences</th><th>Last error</th>
+</tr>
+{{ range .Maintenances }}
+<tr{{ if .IsActive }} class="active"{{ end }}>
+<td>{{ .Name }}{{ with .Source }} ({{ . }}){{ end }}</td>
+<td>{{ range .Matchers }}{{ . }}<br>{{ end }}</td>
+<td>{{ .Schedule }}</td>
+<td>{{ .Status }}</td>
+<td>{{ .Remaining }}</td>
+<td>{{ .Next }}</td>
+<td>{{ range .Silences }}<a href="{{ .URL }}">{{ .Target }}: {{ .ID }}</a><br>{{ end }}</td>
+<td class="error">{{ .LastError }}</td>
+</tr>
+{{ end }}
+</table>
+</body>
+</html>
+`))
+
+type htmlStatusBoard struct {
+	Now           string
+	Alertmanagers []AlertmanagerHealth
+	Maintenances  []htmlMaintenance
+}
+
+type htmlMaintenance struct {
+	Name      string
+	Source    string
+	Matchers  []string
+	Schedule  string
+	Status    string
+	IsActive  bool
+	Remaining string
+	Next      string
+	Silences  []htmlSilence
+	LastError string
+}
+
+type htmlSilence struct {
+	Target string
+	ID     string
+	URL    string
+}
+
+// htmlStatusScheduled is status of recurring maintenance, which is not active
+const htmlStatusScheduled = "scheduled"
+
+// RenderHTML writes health of Alertmanagers and table of maintenances, active ones are highlighted
+// and linked to their silences in Alertmanager UI.
+func (b *StatusBoard) RenderHTML() ([]byte, error) {
+	now := b.clock.Now()
+	alertmanagersHealth := b.alertmanagersHealthStorage.AlertmanagersHealth()
+
+	// silences of target are linked to UI of its first instance
+	uiURLs := make(map[string]string, len(alertmanagersHealth))
+	for _, h := range alertmanagersHealth {
+		if _, ok := uiURLs[h.Target]; !ok && h.UIURL != "" {
+			uiURLs[h.Target] = h.UIURL
+		}
+	}
+
+	maintenances := b.watchedMaintenanceStorage.WatchedMaintenances()
+	groupByAlertmanager(maintenances)
+
+	board := htmlStatusBoard{
+		Now:           now.Format(time.RFC3339),
+		Alertmanagers: alertmanagersHealth,
+		Maintenances:  make([]htmlMaintenance, len(maintenances)),
+	}
+	for i, m := range maintenances {
+		board.Maintenances[i] = newHTMLMaintenance(m, b.yamlMaintenanceIndex.Get(m.Maintenance.Hash), uiURLs, now)
+	}
+
+	buf := bytes.Buffer{}
+	err := statusBoardTemplate.Execute(&buf, board)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func newHTMLMaintenance(m WatchedMaintenance, y YamlMaintenance, uiURLs map[string]string, now time.Time) htmlMaintenance {
+	a := NewAPIMaintenance(m, y)
+
+	result := htmlMaintenance{
+		Name:      a.Identity,
+		Source:    renderableSource(a.Source),
+		Matchers:  make([]string, len(a.Matchers)),
+		Schedule:  a.Schedule,
+		Status:    oneOffStatus(m),
+		IsActive:  a.IsActive,
+		LastError: a.LastError,
+	}
+	if a.ID != "" {
+		result.Name = a.ID
+	}
+
+	for i, matcher := range a.Matchers {
+		operator := "="
+		if matcher.IsRegex {
+			operator = "=~"
+		}
+		result.Matchers[i] = matcher.Name + operator + matcher.Value
+	}
+
+	if a.Recurrence != "" {
+		result.Schedule = a.Recurrence
+	}
+	if result.Schedule == "" {
+		result.Schedule = a.Start + " - " + a.End
+	} else {
+		result.Schedule += " for " + a.Duration
+	}
+
+	if result.Status == "" {
+		result.Status = htmlStatusScheduled
+		if a.IsActive {
+			result.Status = oneOffStatusActive
+		}
+	}
+
+	if a.Window != nil {
+		result.Remaining = a.Window.EndAt.Sub(now).Truncate(time.Second).String()
+	}
+
+	if a.Next != nil {
+		result.Next = a.Next.Format(time.RFC3339)
+	}
+
+	for _, t := range a.Targets {
+		if t.SilenceID == "" {
+			continue
+		}
+
+		result.Silences = append(result.Silences, htmlSilence{
+			Target: t.Name,
+			ID:     t.SilenceID,
+			URL:    silenceURL(uiURLs[t.Name], t.SilenceID),
+		})
+	}
+
+	return result
+}
+
+// silenceURL links silence in Alertmanager UI, it is empty when UI is unknown.
+func silenceURL(uiURL string, silenceID string) string {
+	if uiURL == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(uiURL, "/") + "/#/silences/" + url.PathEscape(silenceID)
+}
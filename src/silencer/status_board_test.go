@@ -1,7 +1,11 @@
 package silencer
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -10,27 +14,35 @@ import (
 
 func TestStatusBoard_Render(t *testing.T) {
 	maintenance1 := YamlMaintenance{
-		[]string{"alertname=test1"},
-		"* * * * *",
-		"50s",
+		Matchers: []string{"alertname=test1"},
+		Schedule: "* * * * *",
+		Duration: "50s",
 	}
 
 	maintenance2 := YamlMaintenance{
-		[]string{"alertname=test2"},
-		"6 * * * *",
-		"30m",
+		Matchers: []string{"alertname=test2"},
+		Schedule: "6 * * * *",
+		Duration: "30m",
+	}
+
+	maintenance3 := YamlMaintenance{
+		Matchers: []string{"alertname=test3"},
+		Start:    "2021-04-07T01:00:00Z",
+		End:      "2021-04-07T05:00:00Z",
 	}
 
 	m1 := MustMaintenance(ParseMaintenance(maintenance1))
 	m2 := MustMaintenance(ParseMaintenance(maintenance2))
+	m3 := MustMaintenance(ParseMaintenance(maintenance3))
 
-	yamlMaintenanceIndex := BuildYamlMaintenanceIndex([]YamlMaintenance{maintenance1, maintenance2})
+	yamlMaintenanceIndex := BuildYamlMaintenanceIndex([]YamlMaintenance{maintenance1, maintenance2, maintenance3})
 
 	now := time.Now()
 
 	testCases := []struct {
 		name                      string
 		watchedMaintenanceStorage watchedMaintenanceStorage
+		alertmanagersHealth       []AlertmanagerHealth
 		expectedStatusBoardRender []byte
 	}{
 		{
@@ -43,9 +55,9 @@ func TestStatusBoard_Render(t *testing.T) {
 			watchedMaintenanceStorage: watchedMaintenanceStorageMock{
 				items: []WatchedMaintenance{
 					{
-						m1,
-						m1.Schedule.Next(now),
-						true,
+						Maintenance: m1,
+						Next:        m1.Schedule.Next(now),
+						IsActive:    true,
 					},
 				},
 			},
@@ -63,14 +75,14 @@ isActive: true
 			watchedMaintenanceStorage: watchedMaintenanceStorageMock{
 				items: []WatchedMaintenance{
 					{
-						m1,
-						m1.Schedule.Next(now),
-						true,
+						Maintenance: m1,
+						Next:        m1.Schedule.Next(now),
+						IsActive:    true,
 					},
 					{
-						m2,
-						m2.Schedule.Next(now),
-						false,
+						Maintenance: m2,
+						Next:        m2.Schedule.Next(now),
+						IsActive:    false,
 					},
 				},
 			},
@@ -91,11 +103,76 @@ next: %s
 isActive: false
 `, m1.Schedule.Next(now).Format(time.RFC3339), m2.Schedule.Next(now).Format(time.RFC3339))),
 		},
+		{
+			name: "finished one-off maintenance",
+			watchedMaintenanceStorage: watchedMaintenanceStorageMock{
+				items: []WatchedMaintenance{
+					{
+						Maintenance: m3,
+						IsFinished:  true,
+					},
+				},
+			},
+			expectedStatusBoardRender: []byte(`maintenance:
+  matchers:
+  - alertname=test3
+  start: "2021-04-07T01:00:00Z"
+  end: "2021-04-07T05:00:00Z"
+isActive: false
+status: finished
+`),
+		},
+		{
+			name: "maintenance in several targets",
+			watchedMaintenanceStorage: watchedMaintenanceStorageMock{
+				items: []WatchedMaintenance{
+					{
+						Maintenance: m3,
+						IsActive:    true,
+						Targets: []WatchedTarget{
+							{Name: "default/am-0", IsActive: true},
+							{Name: "default/am-1", LastError: errors.New("connection refused")},
+						},
+					},
+				},
+			},
+			alertmanagersHealth: []AlertmanagerHealth{
+				{Target: "default/am-0", URL: "http://am-0:9093", LastSuccess: time.Date(2021, 4, 7, 1, 0, 0, 0, time.UTC)},
+				{Target: "default/am-1", URL: "http://am-1:9093", LastError: "connection refused"},
+			},
+			expectedStatusBoardRender: []byte(`alertmanagers:
+- target: default/am-0
+  url: http://am-0:9093
+  lastSuccess: 2021-04-07T01:00:00Z
+- target: default/am-1
+  url: http://am-1:9093
+  lastError: connection refused
+---
+maintenance:
+  matchers:
+  - alertname=test3
+  start: "2021-04-07T01:00:00Z"
+  end: "2021-04-07T05:00:00Z"
+isActive: true
+status: active
+targets:
+- name: default/am-0
+  isActive: true
+- name: default/am-1
+  isActive: false
+  lastError: connection refused
+`),
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			statusBoard := NewStatusBoard(tc.watchedMaintenanceStorage, yamlMaintenanceIndex)
+			statusBoard := NewStatusBoard(
+				tc.watchedMaintenanceStorage,
+				yamlMaintenanceIndex,
+				alertmanagersHealthStorageMock(tc.alertmanagersHealth),
+				ClockMock{time.Now()},
+			)
 			result, err := statusBoard.Render()
 			if err != nil {
 				t.Fatal(err)
@@ -113,3 +190,101 @@ type watchedMaintenanceStorageMock struct {
 func (m watchedMaintenanceStorageMock) WatchedMaintenances() []WatchedMaintenance {
 	return m.items
 }
+
+type alertmanagersHealthStorageMock []AlertmanagerHealth
+
+func (m alertmanagersHealthStorageMock) AlertmanagersHealth() []AlertmanagerHealth {
+	return m
+}
+
+func TestStatusBoardHandler(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 20, 0, 0, time.UTC)
+
+	maintenance := YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"alertname=backup"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}
+	m := MustMaintenance(ParseMaintenance(maintenance))
+
+	statusBoard := NewStatusBoard(
+		watchedMaintenanceStorageMock{
+			items: []WatchedMaintenance{
+				{
+					Maintenance: m,
+					Next:        m.Schedule.Next(now),
+					IsActive:    true,
+					Window:      Window{StartAt: now.Add(-20 * time.Minute), EndAt: now.Add(40 * time.Minute)},
+					Targets: []WatchedTarget{
+						{Name: DefaultTargetName, IsActive: true, SilenceID: "silence-1"},
+					},
+				},
+			},
+		},
+		BuildYamlMaintenanceIndex([]YamlMaintenance{maintenance}),
+		alertmanagersHealthStorageMock{
+			{Target: DefaultTargetName, URL: "http://am:9093", UIURL: "https://alertmanager.example.com/"},
+		},
+		ClockMock{now},
+	)
+	h := NewStatusBoardHandler(statusBoard).Handle()
+
+	testCases := []struct {
+		name                string
+		url                 string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{"default", "/", "", http.StatusOK, "application/yaml; charset=utf-8"},
+		{"any", "/", "*/*", http.StatusOK, "application/yaml; charset=utf-8"},
+		{"browser", "/", "text/html,application/xhtml+xml,*/*;q=0.8", http.StatusOK, "text/html; charset=utf-8"},
+		{"json", "/", "application/json", http.StatusOK, "application/json; charset=utf-8"},
+		{"preferred", "/", "text/html;q=0.5, application/json", http.StatusOK, "application/json; charset=utf-8"},
+		{"unsupported", "/", "image/png", http.StatusOK, "application/yaml; charset=utf-8"},
+		{"format overrides accept", "/?format=json", "text/html", http.StatusOK, "application/json; charset=utf-8"},
+		{"unknown format", "/?format=xml", "", http.StatusBadRequest, "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+		})
+	}
+
+	t.Run("json document", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?format=json", nil))
+
+		board := JSONStatusBoard{}
+		err := json.Unmarshal(w.Body.Bytes(), &board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, board.Alertmanagers, 1)
+		assert.Len(t, board.Maintenances, 1)
+		assert.Equal(t, "backup", board.Maintenances[0].ID)
+		assert.True(t, board.Maintenances[0].IsActive)
+	})
+
+	t.Run("html table", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?format=html", nil))
+
+		body := w.Body.String()
+		assert.Contains(t, body, `<tr class="active">`)
+		assert.Contains(t, body, "<td>40m0s</td>")
+		assert.Contains(t, body, "<td>2021-04-08T03:00:00Z</td>")
+		assert.Contains(t, body, `<a href="https://alertmanager.example.com/#/silences/silence-1">default: silence-1</a>`)
+	})
+}
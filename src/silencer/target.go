@@ -0,0 +1,300 @@
+package silencer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AlertmanagerModeAny is for clustered peers: silences are replicated, so one successful call is enough
+	AlertmanagerModeAny = "any"
+	// AlertmanagerModeAll is for independent instances: every instance gets its own silences
+	AlertmanagerModeAll = "all"
+)
+
+const DefaultTargetName = "default"
+
+// Target is Alertmanager, single instance or cluster of peers, silences of maintenances are managed in.
+// Targets of the same group get the same maintenances.
+type Target struct {
+	group    string
+	name     string
+	silencer silencer
+}
+
+func NewTarget(name string, silencer silencer) Target {
+	return Target{
+		name,
+		name,
+		silencer,
+	}
+}
+
+func (t Target) Name() string {
+	return t.name
+}
+
+func (t Target) Group() string {
+	return t.group
+}
+
+// Routes tells whether maintenance is silenced in target.
+func (t Target) Routes(m Maintenance) bool {
+	return t.group == m.Alertmanager
+}
+
+// BuildTargets creates targets of Alertmanager group: single target failing over between peers
+// in any mode, target per instance in all mode. Health of every instance is tracked, calls are instrumented.
+func BuildTargets(
+	name string,
+	cfg AlertmanagerConfig,
+	policy RetryPolicy,
+	metrics *Metrics,
+	clock clock,
+	logger logrus.FieldLogger,
+) ([]Target, []*HealthTrackingSilencer, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, nil, errors.Errorf("no urls of alertmanager %s", name)
+	}
+
+	healthTrackers := make([]*HealthTrackingSilencer, 0, len(cfg.URLs))
+	for _, u := range cfg.URLs {
+		cli, err := NewAlertmanagerClient(u, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		healthTracker := NewHealthTrackingSilencer(
+			NewInstrumentedSilencer(NewSilenceService(cli.Silence), name, redactedURL(u), metrics),
+			redactedURL(u),
+			clock,
+		)
+		healthTracker.uiURL = healthTracker.url
+		if cfg.ExternalURL != "" {
+			healthTracker.uiURL = cfg.ExternalURL
+		}
+		healthTrackers = append(healthTrackers, healthTracker)
+	}
+
+	switch cfg.Mode {
+	case AlertmanagerModeAny, "":
+		peers := make([]silencer, len(healthTrackers))
+		for i, h := range healthTrackers {
+			h.target = name
+			peers[i] = h
+		}
+
+		return []Target{
+			{name, name, NewRetryingSilencer(NewFailoverSilencer(peers...), policy, logger)},
+		}, healthTrackers, nil
+	case AlertmanagerModeAll:
+		// instances are told apart by host, or by the whole url when they share it
+		hosts := make(map[string]int, len(cfg.URLs))
+		for _, u := range cfg.URLs {
+			hosts[u.Host]++
+		}
+
+		targets := make([]Target, len(healthTrackers))
+		names := make(map[string]struct{}, len(healthTrackers))
+		for i, h := range healthTrackers {
+			h.target = name
+			if len(healthTrackers) > 1 {
+				instance := cfg.URLs[i].Host
+				if hosts[instance] > 1 {
+					instance = redactedURL(cfg.URLs[i])
+				}
+				h.target = name + "/" + instance
+			}
+
+			// target name is the key of its state and metrics
+			if _, ok := names[h.target]; ok {
+				return nil, nil, errors.Errorf("duplicate url %s of alertmanager %s", h.url, name)
+			}
+			names[h.target] = struct{}{}
+
+			targets[i] = Target{name, h.target, NewRetryingSilencer(h, policy, logger)}
+		}
+
+		return targets, healthTrackers, nil
+	default:
+		return nil, nil, errors.Errorf("unknown mode %q of alertmanager %s", cfg.Mode, name)
+	}
+}
+
+// FailoverSilencer calls clustered peers in order until one of them succeeds.
+type FailoverSilencer struct {
+	peers []silencer
+}
+
+func NewFailoverSilencer(peers ...silencer) *FailoverSilencer {
+	return &FailoverSilencer{
+		peers,
+	}
+}
+
+func (s *FailoverSilencer) Add(ctx context.Context, silence Silence) (ActiveSilenceID, error) {
+	var id ActiveSilenceID
+	err := s.failover(func(peer silencer) error {
+		var err error
+		id, err = peer.Add(ctx, silence)
+		return err
+	})
+
+	return id, err
+}
+
+func (s *FailoverSilencer) Delete(ctx context.Context, id ActiveSilenceID) error {
+	return s.failover(func(peer silencer) error {
+		return peer.Delete(ctx, id)
+	})
+}
+
+func (s *FailoverSilencer) SetEnd(ctx context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error) {
+	var newID ActiveSilenceID
+	err := s.failover(func(peer silencer) error {
+		var err error
+		newID, err = peer.SetEnd(ctx, id, endsAt)
+		return err
+	})
+
+	return newID, err
+}
+
+func (s *FailoverSilencer) ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error) {
+	var activeSilences []ActiveSilence
+	err := s.failover(func(peer silencer) error {
+		var err error
+		activeSilences, err = peer.ActiveSilences(ctx, createdBy)
+		return err
+	})
+
+	return activeSilences, err
+}
+
+// failover returns error of the last peer, when all of them fail.
+func (s *FailoverSilencer) failover(call func(peer silencer) error) error {
+	var err error
+	for _, peer := range s.peers {
+		err = call(peer)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// AlertmanagerHealth is outcome of recent calls to Alertmanager instance
+type AlertmanagerHealth struct {
+	Target      string    `yaml:"target" json:"target"`
+	URL         string    `yaml:"url" json:"url"`
+	LastSuccess time.Time `yaml:"lastSuccess,omitempty" json:"lastSuccess"`
+	LastError   string    `yaml:"lastError,omitempty" json:"lastError,omitempty"`
+	LastErrorAt time.Time `yaml:"lastErrorAt,omitempty" json:"lastErrorAt"`
+	// UIURL is Alertmanager UI silences are linked to
+	UIURL string `yaml:"-" json:"-"`
+}
+
+// IsReachable tells whether the last call to Alertmanager succeeded, within maxAge when it is not zero.
+func (h AlertmanagerHealth) IsReachable(now time.Time, maxAge time.Duration) bool {
+	if h.LastSuccess.IsZero() || h.LastSuccess.Before(h.LastErrorAt) {
+		return false
+	}
+
+	return maxAge <= 0 || now.Sub(h.LastSuccess) <= maxAge
+}
+
+// HealthTrackingSilencer remembers outcome of Alertmanager calls.
+type HealthTrackingSilencer struct {
+	silencer silencer
+	target   string
+	url      string
+	uiURL    string
+	clock    clock
+
+	lastSuccess time.Time
+	lastError   error
+	lastErrorAt time.Time
+	mux         sync.RWMutex
+}
+
+func NewHealthTrackingSilencer(silencer silencer, url string, clock clock) *HealthTrackingSilencer {
+	return &HealthTrackingSilencer{
+		silencer: silencer,
+		url:      url,
+		clock:    clock,
+	}
+}
+
+func (s *HealthTrackingSilencer) Add(ctx context.Context, silence Silence) (ActiveSilenceID, error) {
+	id, err := s.silencer.Add(ctx, silence)
+	s.track(err)
+
+	return id, err
+}
+
+func (s *HealthTrackingSilencer) Delete(ctx context.Context, id ActiveSilenceID) error {
+	err := s.silencer.Delete(ctx, id)
+	s.track(err)
+
+	return err
+}
+
+func (s *HealthTrackingSilencer) SetEnd(ctx context.Context, id ActiveSilenceID, endsAt time.Time) (ActiveSilenceID, error) {
+	newID, err := s.silencer.SetEnd(ctx, id, endsAt)
+	s.track(err)
+
+	return newID, err
+}
+
+func (s *HealthTrackingSilencer) ActiveSilences(ctx context.Context, createdBy string) ([]ActiveSilence, error) {
+	activeSilences, err := s.silencer.ActiveSilences(ctx, createdBy)
+	s.track(err)
+
+	return activeSilences, err
+}
+
+// track counts only failures of Alertmanager itself, client errors mean it is reachable.
+func (s *HealthTrackingSilencer) track(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.clock.Now()
+	if err == nil || IsPermanentError(err) {
+		s.lastSuccess = now
+		return
+	}
+
+	s.lastError = err
+	s.lastErrorAt = now
+}
+
+func (s *HealthTrackingSilencer) Health() AlertmanagerHealth {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return AlertmanagerHealth{
+		Target:      s.target,
+		URL:         s.url,
+		UIURL:       s.uiURL,
+		LastSuccess: s.lastSuccess,
+		LastError:   errorString(s.lastError),
+		LastErrorAt: s.lastErrorAt,
+	}
+}
+
+// AlertmanagersHealth is health of all Alertmanager instances
+type AlertmanagersHealth []*HealthTrackingSilencer
+
+func (h AlertmanagersHealth) AlertmanagersHealth() []AlertmanagerHealth {
+	result := make([]AlertmanagerHealth, len(h))
+	for i, s := range h {
+		result[i] = s.Health()
+	}
+
+	return result
+}
@@ -0,0 +1,147 @@
+package silencer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverSilencer_Add(t *testing.T) {
+	testCases := []struct {
+		name             string
+		peers            []*failingSilencerMock
+		expectedAttempts []int
+		expectedErr      bool
+	}{
+		{
+			name: "first peer is enough",
+			peers: []*failingSilencerMock{
+				{},
+				{},
+			},
+			expectedAttempts: []int{1, 0},
+		},
+		{
+			name: "failed peer is skipped",
+			peers: []*failingSilencerMock{
+				{errors: []error{errors.New("connection refused")}},
+				{},
+			},
+			expectedAttempts: []int{1, 1},
+		},
+		{
+			name: "all peers failed",
+			peers: []*failingSilencerMock{
+				{errors: []error{errors.New("connection refused")}},
+				{errors: []error{errors.New("connection refused")}},
+			},
+			expectedAttempts: []int{1, 1},
+			expectedErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			peers := make([]silencer, len(tc.peers))
+			for i, p := range tc.peers {
+				peers[i] = p
+			}
+
+			_, err := NewFailoverSilencer(peers...).Add(context.Background(), Silence{})
+			assert.Equal(t, tc.expectedErr, err != nil)
+
+			attempts := make([]int, len(tc.peers))
+			for i, p := range tc.peers {
+				attempts[i] = p.attempts
+			}
+			assert.Equal(t, tc.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestBuildTargets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		urls     []string
+		mode     string
+		expected []string
+		isValid  bool
+	}{
+		{
+			name:     "any mode",
+			urls:     []string{"http://am-1:9093", "http://am-2:9093"},
+			mode:     AlertmanagerModeAny,
+			expected: []string{"main"},
+			isValid:  true,
+		},
+		{
+			name:     "all mode",
+			urls:     []string{"http://am-1:9093", "http://am-2:9093"},
+			mode:     AlertmanagerModeAll,
+			expected: []string{"main/am-1:9093", "main/am-2:9093"},
+			isValid:  true,
+		},
+		{
+			name:     "all mode, instances share host",
+			urls:     []string{"http://am/a", "http://am/b", "http://other"},
+			mode:     AlertmanagerModeAll,
+			expected: []string{"main/http://am/a", "main/http://am/b", "main/other"},
+			isValid:  true,
+		},
+		{
+			name: "all mode, duplicate url",
+			urls: []string{"http://am/a", "http://am/a"},
+			mode: AlertmanagerModeAll,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			urls, err := ParseAlertmanagerURLs(tc.urls)
+			require.NoError(t, err)
+
+			targets, _, err := BuildTargets(
+				"main",
+				AlertmanagerConfig{URLs: urls, Mode: tc.mode},
+				DefaultRetryPolicy(),
+				NewMetrics(prometheus.NewRegistry()),
+				Clock{},
+				logrus.New(),
+			)
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			names := make([]string, len(targets))
+			for i, target := range targets {
+				names[i] = target.Name()
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestHealthTrackingSilencer(t *testing.T) {
+	now := time.Now()
+
+	failing := &failingSilencerMock{errors: []error{errors.New("connection refused")}}
+	s := NewHealthTrackingSilencer(failing, "http://alertmanager:9093", ClockMock{now})
+
+	_, _ = s.Add(context.Background(), Silence{})
+	assert.Equal(t, AlertmanagerHealth{
+		URL:         "http://alertmanager:9093",
+		LastError:   "connection refused",
+		LastErrorAt: now,
+	}, s.Health())
+
+	_, _ = s.Add(context.Background(), Silence{})
+	assert.Equal(t, now, s.Health().LastSuccess)
+}
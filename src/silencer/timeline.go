@@ -0,0 +1,200 @@
+package silencer
+
+import (
+	"sort"
+	"time"
+)
+
+// defaultTimelineRange is how far timeline reaches into the past and into the future by default
+const defaultTimelineRange = 7 * 24 * time.Hour
+
+// maxTimelineRange limits range of timeline, so it could not be built of too many windows
+const maxTimelineRange = 92 * 24 * time.Hour
+
+type APITimeline struct {
+	From         time.Time                `json:"from"`
+	To           time.Time                `json:"to"`
+	Now          time.Time                `json:"now"`
+	Maintenances []APITimelineMaintenance `json:"maintenances"`
+}
+
+type APITimelineMaintenance struct {
+	Identity     string       `json:"identity"`
+	ID           string       `json:"id,omitempty"`
+	Matchers     []APIMatcher `json:"matchers"`
+	Alertmanager string       `json:"alertmanager"`
+	// Windows are computed from schedule and duration, exceptions included
+	Windows []APITimelineWindow `json:"windows"`
+	// Silences are periods maintenance was actually silenced for, according to history
+	Silences []APITimelineSilence `json:"silences"`
+}
+
+type APITimelineWindow struct {
+	APIOccurrence
+	// Overlaps are identities of other maintenances, which windows overlap this one
+	Overlaps []string `json:"overlaps,omitempty"`
+}
+
+type APITimelineSilence struct {
+	Target    string    `json:"target"`
+	SilenceID string    `json:"silenceId"`
+	StartAt   time.Time `json:"startAt"`
+	EndAt     time.Time `json:"endAt"`
+	// IsDeleted tells that silence was deleted before end of its window
+	IsDeleted bool `json:"isDeleted"`
+}
+
+// BuildTimeline lays out windows of maintenances overlapping [from, to] along with silences of history.
+// History is expected in order it was recorded, windows of different maintenances open at the same time
+// are marked as overlapping, skipped ones are not.
+func BuildTimeline(maintenances []WatchedMaintenance, history []HistoryEntry, from, to, now time.Time) APITimeline {
+	result := APITimeline{
+		From:         from,
+		To:           to,
+		Now:          now,
+		Maintenances: make([]APITimelineMaintenance, len(maintenances)),
+	}
+
+	silences := timelineSilences(history)
+	for i, m := range maintenances {
+		a := NewAPIMaintenance(m, YamlMaintenance{})
+		tm := APITimelineMaintenance{
+			Identity:     a.Identity,
+			ID:           a.ID,
+			Matchers:     a.Matchers,
+			Alertmanager: a.Alertmanager,
+			Windows:      make([]APITimelineWindow, 0),
+			Silences:     make([]APITimelineSilence, 0),
+		}
+
+		for _, o := range Occurrences(m.Maintenance, m.Exceptions, from, to, now) {
+			tm.Windows = append(tm.Windows, APITimelineWindow{APIOccurrence: o})
+		}
+
+		for _, s := range silences[m.Maintenance.Hash] {
+			if s.EndAt.After(from) && !s.StartAt.After(to) {
+				tm.Silences = append(tm.Silences, s)
+			}
+		}
+
+		result.Maintenances[i] = tm
+	}
+
+	markOverlaps(result.Maintenances)
+
+	return result
+}
+
+// timelineWindowRef points at window of timeline maintenance
+type timelineWindowRef struct {
+	maintenance int
+	window      int
+	startAt     time.Time
+	endAt       time.Time
+}
+
+func markOverlaps(maintenances []APITimelineMaintenance) {
+	refs := make([]timelineWindowRef, 0)
+	for i, m := range maintenances {
+		for j, w := range m.Windows {
+			if w.Exception != nil && w.Exception.Action == ExceptionActionSkip {
+				continue
+			}
+			refs = append(refs, timelineWindowRef{i, j, w.StartAt, w.EndAt})
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].startAt.Before(refs[j].startAt)
+	})
+
+	for i, a := range refs {
+		for _, b := range refs[i+1:] {
+			if !b.startAt.Before(a.endAt) {
+				break
+			}
+			if a.maintenance == b.maintenance {
+				continue
+			}
+
+			addOverlap(&maintenances[a.maintenance].Windows[a.window], maintenances[b.maintenance].Identity)
+			addOverlap(&maintenances[b.maintenance].Windows[b.window], maintenances[a.maintenance].Identity)
+		}
+	}
+}
+
+func addOverlap(w *APITimelineWindow, identity string) {
+	for _, o := range w.Overlaps {
+		if o == identity {
+			return
+		}
+	}
+
+	w.Overlaps = append(w.Overlaps, identity)
+}
+
+// timelineSilences replays silence events of history into periods silences were in effect, by maintenance.
+// Silence lasts for its window, updates move its end, deletion before end of window cuts it short.
+// Silence deleted before its window started never was in effect, so it is dropped.
+func timelineSilences(history []HistoryEntry) map[MaintenanceHash][]APITimelineSilence {
+	type silenceKey struct {
+		maintenance MaintenanceHash
+		target      string
+		silenceID   ActiveSilenceID
+	}
+
+	result := make(map[MaintenanceHash][]APITimelineSilence)
+	open := make(map[silenceKey]int)
+	for _, e := range history {
+		if e.Window == nil && e.Event != HistoryEventSilenceDeleted {
+			continue
+		}
+
+		silences := result[e.Maintenance]
+		switch e.Event {
+		case HistoryEventSilenceCreated:
+			open[silenceKey{e.Maintenance, e.Target, e.SilenceID}] = len(silences)
+			result[e.Maintenance] = append(silences, APITimelineSilence{
+				Target:    e.Target,
+				SilenceID: string(e.SilenceID),
+				StartAt:   e.Window.StartAt,
+				EndAt:     e.Window.EndAt,
+			})
+		case HistoryEventSilenceUpdated:
+			// update could replace silence, so it is matched by window start instead of id
+			for key, i := range open {
+				if key.maintenance == e.Maintenance && key.target == e.Target && silences[i].StartAt.Equal(e.Window.StartAt) {
+					delete(open, key)
+					open[silenceKey{e.Maintenance, e.Target, e.SilenceID}] = i
+					silences[i].SilenceID = string(e.SilenceID)
+					silences[i].EndAt = e.Window.EndAt
+					break
+				}
+			}
+		case HistoryEventSilenceDeleted:
+			key := silenceKey{e.Maintenance, e.Target, e.SilenceID}
+			i, ok := open[key]
+			if !ok {
+				continue
+			}
+			delete(open, key)
+
+			if e.At.Before(silences[i].EndAt) {
+				silences[i].EndAt = e.At
+				silences[i].IsDeleted = true
+			}
+		}
+	}
+
+	for hash, silences := range result {
+		kept := silences[:0]
+		for _, s := range silences {
+			if s.EndAt.After(s.StartAt) {
+				kept = append(kept, s)
+			}
+		}
+		result[hash] = kept
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package silencer
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// TimelineHandler serves windows of maintenances over range of time along with silences of history,
+// as JSON for API and as HTML page.
+type TimelineHandler struct {
+	watchedMaintenanceStorage watchedMaintenanceStorage
+	history                   historyReader
+	clock                     clock
+}
+
+func NewTimelineHandler(
+	watchedMaintenanceStorage watchedMaintenanceStorage,
+	history historyReader,
+	clock clock,
+) *TimelineHandler {
+	return &TimelineHandler{
+		watchedMaintenanceStorage,
+		history,
+		clock,
+	}
+}
+
+// Register mounts handlers under r, usually /api/v1.
+func (h *TimelineHandler) Register(r chi.Router) {
+	r.Get("/timeline", h.JSON())
+}
+
+// JSON returns timeline between from and to (RFC3339), the last week and the next one by default.
+func (h *TimelineHandler) JSON() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timeline, status, err := h.timeline(r)
+		if err != nil {
+			writeAPIError(w, status, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, timeline)
+	}
+}
+
+// HTML renders timeline as Gantt chart, it takes the same parameters as JSON.
+func (h *TimelineHandler) HTML() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timeline, status, err := h.timeline(r)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		page, err := RenderTimelineHTML(timeline)
+		if err != nil {
+			http.Error(w, http.StatusText(500), 500)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}
+}
+
+func (h *TimelineHandler) timeline(r *http.Request) (APITimeline, int, error) {
+	now := h.clock.Now()
+	from, err := parseTimeParam(r, "from", now.Add(-defaultTimelineRange))
+	if err != nil {
+		return APITimeline{}, http.StatusBadRequest, err
+	}
+
+	to, err := parseTimeParam(r, "to", now.Add(defaultTimelineRange))
+	if err != nil {
+		return APITimeline{}, http.StatusBadRequest, err
+	}
+
+	if !to.After(from) {
+		return APITimeline{}, http.StatusBadRequest, errors.New("to is not after from")
+	}
+
+	if to.Sub(from) > maxTimelineRange {
+		return APITimeline{}, http.StatusBadRequest, errors.Errorf("range is longer than %s", maxTimelineRange)
+	}
+
+	// silences created before from could be in effect within range, so history is read from the beginning
+	history, err := h.history.Entries(HistoryFilter{To: to})
+	if err != nil {
+		return APITimeline{}, http.StatusInternalServerError, err
+	}
+
+	return BuildTimeline(h.watchedMaintenanceStorage.WatchedMaintenances(), history, from, to, now), http.StatusOK, nil
+}
@@ -0,0 +1,237 @@
+package silencer
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// maxTimelineTicks limits amount of date marks on timeline page, step between them grows for longer ranges
+const maxTimelineTicks = 31
+
+var timelineTemplate = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Silencer timeline</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+.row { display: flex; border-bottom: 1px solid #eee; }
+.name { width: 240px; flex: none; padding: 4px 8px; overflow: hidden; }
+.matchers { color: #666; font-size: 12px; }
+.track { position: relative; flex: auto; height: 40px; }
+.ticks { height: 20px; }
+.tick { position: absolute; top: 0; bottom: 0; border-left: 1px solid #ddd; padding-left: 2px; font-size: 11px; color: #666; }
+.now { position: absolute; top: 0; bottom: 0; border-left: 2px solid #d00; }
+.bar { position: absolute; min-width: 2px; box-sizing: border-box; }
+.window { top: 4px; height: 16px; background: #9cc3ef; }
+.window.active { background: #3a7fd0; }
+.window.overlap { background: #f2a33a; border: 1px solid #c00; }
+.window.skipped { background: repeating-linear-gradient(45deg, #eee, #eee 4px, #ccc 4px, #ccc 8px); }
+.silence { top: 24px; height: 10px; background: #5cb85c; }
+.silence.deleted { background: #999; }
+.legend span { display: inline-block; padding: 0 8px; margin-right: 8px; }
+</style>
+</head>
+<body>
+<p>{{ .From }} &ndash; {{ .To }}, rendered at {{ .Now }}</p>
+<p class="legend">
+<span class="window">scheduled</span>
+<span class="window active">active</span>
+<span class="window overlap">overlapping</span>
+<span class="window skipped">skipped</span>
+<span class="silence">silenced</span>
+<span class="silence deleted">silenced, deleted early</span>
+</p>
+<div class="row">
+<div class="name"></div>
+<div class="track ticks">
+{{ range .Ticks }}<div class="tick" style="left: {{ .Left }}%">{{ .Title }}</div>{{ end }}
+</div>
+</div>
+{{ range .Maintenances }}
+<div class="row">
+<div class="name">{{ .Name }}<div class="matchers">{{ range .Matchers }}{{ . }} {{ end }}</div></div>
+<div class="track">
+{{ range .Bars }}
+<div class="bar {{ .Class }}" style="left: {{ .Left }}%; width: {{ .Width }}%" title="{{ .Title }}"></div>
+{{ end }}
+{{ with $.NowLeft }}<div class="now" style="left: {{ . }}%"></div>{{ end }}
+</div>
+</div>
+{{ end }}
+</body>
+</html>
+`))
+
+type htmlTimeline struct {
+	From         string
+	To           string
+	Now          string
+	NowLeft      float64
+	Ticks        []htmlTimelineBar
+	Maintenances []htmlTimelineMaintenance
+}
+
+type htmlTimelineMaintenance struct {
+	Name     string
+	Matchers []string
+	Bars     []htmlTimelineBar
+}
+
+// htmlTimelineBar is positioned on track in percents of timeline range
+type htmlTimelineBar struct {
+	Class string
+	Left  float64
+	Width float64
+	Title string
+}
+
+// RenderTimelineHTML draws timeline as Gantt chart: windows of every maintenance with silences of history
+// below them, overlapping windows are highlighted.
+func RenderTimelineHTML(timeline APITimeline) ([]byte, error) {
+	scale := newTimelineScale(timeline.From, timeline.To)
+
+	page := htmlTimeline{
+		From:         timeline.From.Format(time.RFC3339),
+		To:           timeline.To.Format(time.RFC3339),
+		Now:          timeline.Now.Format(time.RFC3339),
+		Ticks:        scale.ticks(timeline.Now.Location()),
+		Maintenances: make([]htmlTimelineMaintenance, len(timeline.Maintenances)),
+	}
+	if timeline.Now.After(timeline.From) && timeline.Now.Before(timeline.To) {
+		page.NowLeft = scale.position(timeline.Now)
+	}
+
+	names := make(map[string]string, len(timeline.Maintenances))
+	for _, m := range timeline.Maintenances {
+		names[m.Identity] = m.Identity
+		if m.ID != "" {
+			names[m.Identity] = m.ID
+		}
+	}
+
+	for i, m := range timeline.Maintenances {
+		hm := htmlTimelineMaintenance{
+			Name:     names[m.Identity],
+			Matchers: make([]string, len(m.Matchers)),
+		}
+
+		for j, matcher := range m.Matchers {
+			operator := "="
+			if matcher.IsRegex {
+				operator = "=~"
+			}
+			hm.Matchers[j] = matcher.Name + operator + matcher.Value
+		}
+
+		for _, w := range m.Windows {
+			hm.Bars = append(hm.Bars, scale.bar(timelineWindowClass(w), w.StartAt, w.EndAt, timelineWindowTitle(w, names)))
+		}
+
+		for _, s := range m.Silences {
+			class, title := "silence", s.Target+": "+s.SilenceID
+			if s.IsDeleted {
+				class += " deleted"
+			}
+			hm.Bars = append(hm.Bars, scale.bar(class, s.StartAt, s.EndAt, title))
+		}
+
+		page.Maintenances[i] = hm
+	}
+
+	buf := bytes.Buffer{}
+	err := timelineTemplate.Execute(&buf, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func timelineWindowClass(w APITimelineWindow) string {
+	classes := []string{"window"}
+	if w.IsActive {
+		classes = append(classes, "active")
+	}
+	if len(w.Overlaps) > 0 {
+		classes = append(classes, "overlap")
+	}
+	if w.Exception != nil && w.Exception.Action == ExceptionActionSkip {
+		classes = append(classes, "skipped")
+	}
+
+	return strings.Join(classes, " ")
+}
+
+// timelineWindowTitle describes window, overlapping maintenances are referred by names.
+func timelineWindowTitle(w APITimelineWindow, names map[string]string) string {
+	title := w.StartAt.Format(time.RFC3339) + " - " + w.EndAt.Format(time.RFC3339)
+	if w.Exception != nil {
+		title += ", " + w.Exception.Action + " by " + w.Exception.Actor
+	}
+	if len(w.Overlaps) > 0 {
+		overlaps := make([]string, len(w.Overlaps))
+		for i, identity := range w.Overlaps {
+			overlaps[i] = names[identity]
+		}
+		title += ", overlaps " + strings.Join(overlaps, ", ")
+	}
+
+	return title
+}
+
+// timelineScale maps time onto track of timeline page
+type timelineScale struct {
+	from time.Time
+	to   time.Time
+}
+
+func newTimelineScale(from, to time.Time) timelineScale {
+	return timelineScale{from, to}
+}
+
+// position is offset of t from start of track in percents, it is clamped to track.
+func (s timelineScale) position(t time.Time) float64 {
+	switch {
+	case t.Before(s.from):
+		return 0
+	case t.After(s.to):
+		return 100
+	}
+
+	return float64(t.Sub(s.from)) / float64(s.to.Sub(s.from)) * 100
+}
+
+func (s timelineScale) bar(class string, startAt, endAt time.Time, title string) htmlTimelineBar {
+	left := s.position(startAt)
+	return htmlTimelineBar{
+		Class: class,
+		Left:  left,
+		Width: s.position(endAt) - left,
+		Title: title,
+	}
+}
+
+// ticks marks midnights of loc within range, every day or every few days for long ranges.
+func (s timelineScale) ticks(loc *time.Location) []htmlTimelineBar {
+	days := int(s.to.Sub(s.from)/(24*time.Hour)) + 1
+	step := (days + maxTimelineTicks - 1) / maxTimelineTicks
+
+	from := s.from.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	if day.Before(s.from) {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	result := make([]htmlTimelineBar, 0)
+	for ; !day.After(s.to); day = day.AddDate(0, 0, step) {
+		result = append(result, htmlTimelineBar{
+			Left:  s.position(day),
+			Title: day.Format("Mon 01-02"),
+		})
+	}
+
+	return result
+}
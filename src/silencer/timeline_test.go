@@ -0,0 +1,161 @@
+package silencer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 30, 0, 0, time.UTC)
+	from := time.Date(2021, 4, 6, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 4, 8, 0, 0, 0, 0, time.UTC)
+
+	backup := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"job=db"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	vacuum := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "vacuum",
+		Matchers: []string{"job=db"},
+		Schedule: "30 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	deploy := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "deploy",
+		Matchers: []string{"job=web"},
+		Start:    "2021-04-06T12:00:00Z",
+		End:      "2021-04-06T13:00:00Z",
+	}))
+
+	window := func(day int, hour int, minute int) *APIWindow {
+		startAt := time.Date(2021, 4, day, hour, minute, 0, 0, time.UTC)
+		return &APIWindow{startAt, startAt.Add(time.Hour)}
+	}
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2021, 4, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	entry := func(at time.Time, event string, hash MaintenanceHash, silenceID ActiveSilenceID, w *APIWindow) HistoryEntry {
+		return HistoryEntry{At: at, Event: event, Maintenance: hash, Target: DefaultTargetName, SilenceID: silenceID, Window: w}
+	}
+
+	history := []HistoryEntry{
+		entry(at(6, 2, 0), HistoryEventSilenceCreated, backup.Hash, "s1", window(6, 3, 0)),
+		entry(at(6, 3, 20), HistoryEventSilenceDeleted, backup.Hash, "s1", window(6, 3, 0)),
+		entry(at(6, 2, 30), HistoryEventSilenceCreated, vacuum.Hash, "s2", window(6, 3, 30)),
+		entry(at(6, 4, 0), HistoryEventSilenceUpdated, vacuum.Hash, "s3", &APIWindow{at(6, 3, 30), at(6, 5, 0)}),
+		entry(at(6, 5, 0), HistoryEventSilenceDeleted, vacuum.Hash, "s3", window(6, 3, 30)),
+		// pending silence deleted before its window is not shown
+		entry(at(6, 11, 0), HistoryEventSilenceCreated, deploy.Hash, "s4", window(6, 12, 0)),
+		entry(at(6, 11, 30), HistoryEventSilenceDeleted, deploy.Hash, "s4", nil),
+	}
+
+	timeline := BuildTimeline(
+		[]WatchedMaintenance{{Maintenance: backup}, {Maintenance: vacuum}, {Maintenance: deploy}},
+		history,
+		from,
+		to,
+		now,
+	)
+
+	assert.Len(t, timeline.Maintenances, 3)
+
+	backupTimeline := timeline.Maintenances[0]
+	assert.Len(t, backupTimeline.Windows, 2)
+	for _, w := range backupTimeline.Windows {
+		assert.Equal(t, []string{vacuum.Hash.String()}, w.Overlaps)
+	}
+	assert.True(t, backupTimeline.Windows[1].IsActive)
+	assert.Equal(t, []APITimelineSilence{
+		{Target: "default", SilenceID: "s1", StartAt: at(6, 3, 0), EndAt: at(6, 3, 20), IsDeleted: true},
+	}, backupTimeline.Silences)
+
+	vacuumTimeline := timeline.Maintenances[1]
+	assert.Equal(t, []APITimelineSilence{
+		{Target: "default", SilenceID: "s3", StartAt: at(6, 3, 30), EndAt: at(6, 5, 0)},
+	}, vacuumTimeline.Silences, "extended silence lasts till its new end")
+
+	deployTimeline := timeline.Maintenances[2]
+	assert.Len(t, deployTimeline.Windows, 1)
+	assert.Empty(t, deployTimeline.Windows[0].Overlaps)
+	assert.Empty(t, deployTimeline.Silences)
+}
+
+func TestTimelineHandler(t *testing.T) {
+	now := time.Date(2021, 4, 7, 3, 30, 0, 0, time.UTC)
+
+	backup := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "backup",
+		Matchers: []string{"job=db"},
+		Schedule: "0 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+	vacuum := MustMaintenance(ParseMaintenance(YamlMaintenance{
+		ID:       "vacuum",
+		Matchers: []string{"job=db"},
+		Schedule: "30 3 * * *",
+		Duration: "1h",
+		Timezone: "UTC",
+	}))
+
+	history := NewMemoryHistory(ClockMock{now.Add(-time.Hour)})
+	history.Record(HistoryEntry{
+		Event:       HistoryEventSilenceCreated,
+		Maintenance: backup.Hash,
+		Target:      DefaultTargetName,
+		SilenceID:   "s1",
+		Window:      &APIWindow{now.Add(-30 * time.Minute), now.Add(30 * time.Minute)},
+	})
+
+	h := NewTimelineHandler(
+		watchedMaintenanceStorageMock{items: []WatchedMaintenance{{Maintenance: backup}, {Maintenance: vacuum}}},
+		history,
+		ClockMock{now},
+	)
+	r := chi.NewRouter()
+	h.Register(r)
+	r.Get("/timeline.html", h.HTML())
+
+	w := serveJSON(r, http.MethodGet, "/timeline", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	timeline := APITimeline{}
+	err := json.Unmarshal(w.Body.Bytes(), &timeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, now.Add(-defaultTimelineRange), timeline.From)
+	assert.Equal(t, now.Add(defaultTimelineRange), timeline.To)
+	assert.Len(t, timeline.Maintenances, 2)
+	assert.Len(t, timeline.Maintenances[0].Windows, 15)
+	assert.Len(t, timeline.Maintenances[0].Silences, 1)
+
+	w = serveJSON(r, http.MethodGet, "/timeline?from=2021-04-08T00:00:00Z&to=2021-04-07T00:00:00Z", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "to before from")
+
+	w = serveJSON(r, http.MethodGet, "/timeline?from=2021-01-01T00:00:00Z&to=2021-12-31T00:00:00Z", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "too long range")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/timeline.html?from=2021-04-07T00:00:00Z&to=2021-04-08T00:00:00Z", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, `<div class="bar window active overlap" style="left: 12.5%; width: 4.1666`)
+	assert.Contains(t, body, `title="2021-04-07T03:00:00Z - 2021-04-07T04:00:00Z, overlaps vacuum"`)
+	assert.Contains(t, body, `<div class="bar silence" style="left: 12.5%; width: 4.1666`)
+	assert.Equal(t, 2, strings.Count(body, `<div class="now" style="left: 14.5833`), "now is marked in every row")
+}
@@ -3,27 +3,85 @@ package silencer
 import (
 	"io"
 	"strings"
+	"sync"
 
+	commoncfg "github.com/prometheus/common/config"
 	uuid "github.com/satori/go.uuid"
 	"gopkg.in/yaml.v2"
 )
 
 type YamlMaintenance struct {
+	ID       string   `yaml:"id,omitempty"`
 	Matchers []string `yaml:"matchers"`
-	Schedule string   `yaml:"schedule"`
-	Duration string   `yaml:"duration"`
+	Schedule string   `yaml:"schedule,omitempty"`
+	// Recurrence is RFC 5545 recurrence rule with DTSTART, alternative to schedule, e.g. for the last Sunday of month
+	Recurrence string `yaml:"recurrence,omitempty"`
+	Duration   string `yaml:"duration,omitempty"`
+	Start      string `yaml:"start,omitempty"`
+	End        string `yaml:"end,omitempty"`
+	Timezone   string `yaml:"timezone,omitempty"`
+	// Alertmanager is name of alertmanagers entry maintenance is silenced in, alertmanager section is used by default
+	Alertmanager string `yaml:"alertmanager,omitempty"`
+	// Author, Comment and Tags describe maintenance, they are not part of its content
+	Author  string   `yaml:"author,omitempty"`
+	Comment string   `yaml:"comment,omitempty"`
+	Tags    []string `yaml:"tags,omitempty"`
 }
 
+// Identity is derived from id when it is set, so maintenance could be edited without losing its silences.
+// Content hash is used otherwise.
+func (m YamlMaintenance) Identity() MaintenanceHash {
+	if m.ID == "" {
+		return m.Hash()
+	}
+
+	return MaintenanceHash(uuid.NewV5(uuid.UUID{}, "id:"+m.ID))
+}
+
+// Hash is content based maintenance identity.
 func (m YamlMaintenance) Hash() MaintenanceHash {
 	value := strings.Join(m.Matchers, ",") +
 		m.Schedule +
-		m.Duration
+		m.Recurrence +
+		m.Duration +
+		m.Start +
+		m.End +
+		m.Timezone +
+		m.Alertmanager
 
 	return MaintenanceHash(uuid.NewV5(uuid.UUID{}, value))
 }
 
+// YamlAlertmanager is alertmanager section of config file, it is applied on start.
+type YamlAlertmanager struct {
+	URL        string                      `yaml:"url,omitempty"`
+	URLs       []string                    `yaml:"urls,omitempty"`
+	Mode       string                      `yaml:"mode,omitempty"`
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty"`
+	Headers    map[string]string           `yaml:"headers,omitempty"`
+	// ExternalURL is Alertmanager UI, e.g. behind ingress, status board links silences to
+	ExternalURL string `yaml:"external_url,omitempty"`
+}
+
+// YamlCalendar is entry of calendars section, events of iCalendar file become maintenances.
+type YamlCalendar struct {
+	// File is path of .ics file, relative one is resolved against directory of config file
+	File string `yaml:"file"`
+	// Matchers are added to matchers of every event
+	Matchers []string `yaml:"matchers,omitempty"`
+	// Properties map event properties to label names, e.g. LOCATION: instance. Events lacking any of them are skipped.
+	Properties map[string]string `yaml:"properties,omitempty"`
+	// Timezone is location of floating times and dates, local one by default
+	Timezone     string `yaml:"timezone,omitempty"`
+	Alertmanager string `yaml:"alertmanager,omitempty"`
+}
+
 type YamlConfig struct {
-	Maintenances []YamlMaintenance `yaml:"maintenances,omitempty"`
+	Alertmanager *YamlAlertmanager `yaml:"alertmanager,omitempty"`
+	// Alertmanagers are named Alertmanagers maintenances could be routed to, they are applied on start
+	Alertmanagers map[string]*YamlAlertmanager `yaml:"alertmanagers,omitempty"`
+	Maintenances  []YamlMaintenance            `yaml:"maintenances,omitempty"`
+	Calendars     []YamlCalendar               `yaml:"calendars,omitempty"`
 }
 
 func ParseYaml(reader io.Reader) (YamlConfig, error) {
@@ -43,8 +101,38 @@ func BuildYamlMaintenanceIndex(maintenances []YamlMaintenance) YamlMaintenanceIn
 	index := make(YamlMaintenanceIndex)
 
 	for _, m := range maintenances {
-		index[m.Hash()] = m
+		index[m.Identity()] = m
 	}
 
 	return index
 }
+
+func (index YamlMaintenanceIndex) Get(hash MaintenanceHash) YamlMaintenance {
+	return index[hash]
+}
+
+type ReloadableYamlMaintenanceIndex struct {
+	index YamlMaintenanceIndex
+	mux   sync.RWMutex
+}
+
+func NewReloadableYamlMaintenanceIndex(index YamlMaintenanceIndex) *ReloadableYamlMaintenanceIndex {
+	return &ReloadableYamlMaintenanceIndex{
+		index,
+		sync.RWMutex{},
+	}
+}
+
+func (i *ReloadableYamlMaintenanceIndex) Set(index YamlMaintenanceIndex) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	i.index = index
+}
+
+func (i *ReloadableYamlMaintenanceIndex) Get(hash MaintenanceHash) YamlMaintenance {
+	i.mux.RLock()
+	defer i.mux.RUnlock()
+
+	return i.index.Get(hash)
+}
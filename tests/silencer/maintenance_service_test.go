@@ -18,52 +18,64 @@ import (
 )
 
 func TestMaintenanceService(t *testing.T) {
-	now := time.Now().Truncate(time.Minute).Add(40 * time.Second)
+	// clock is ahead of Alertmanager one, so silences of the current window are not over on Alertmanager side
+	windowStart := time.Now().Add(time.Minute).Truncate(time.Minute)
+	now := windowStart.Add(40 * time.Second)
 	clockMock := silencer.ClockMock{
-		now,
+		T: now,
 	}
 
 	maintenance1 := silencer.YamlMaintenance{
-		[]string{"alertname=test1"},
-		"* * * * *",
-		"50s",
+		Matchers: []string{"alertname=test1"},
+		Schedule: "* * * * *",
+		Duration: "50s",
 	}
 
 	maintenance2 := silencer.YamlMaintenance{
-		[]string{"alertname=test2"},
-		"* * * * *",
-		"20s",
+		Matchers: []string{"alertname=test2"},
+		Schedule: "* * * * *",
+		Duration: "20s",
 	}
 
 	m1 := silencer.MustMaintenance(silencer.ParseMaintenance(maintenance1))
 	m2 := silencer.MustMaintenance(silencer.ParseMaintenance(maintenance2))
 
+	// silence1 is silence of the current window of maintenance1, it is recovered
 	silence1 := silencer.Silence{
-		m1.Matchers,
-		now,
-		m1.Duration,
-		m1.Hash.String(),
-		"maintenance service",
+		Matchers:  m1.Matchers,
+		StartAt:   windowStart,
+		Duration:  m1.Duration,
+		Comment:   m1.Hash.String(),
+		CreatedBy: "maintenance service",
 	}
 
 	silence2 := silencer.Silence{
-		mustTypeMatchers(cli.TypeMatchers([]labels.Matcher{
+		Matchers: mustTypeMatchers(cli.TypeMatchers([]labels.Matcher{
 			mustParseMatcher(labels.ParseMatcher("alertname=test1")),
 		})),
-		now,
-		time.Minute,
-		uuid.NewV4().String(),
-		"maintenance service",
+		StartAt:   now,
+		Duration:  time.Minute,
+		Comment:   uuid.NewV4().String(),
+		CreatedBy: "maintenance service",
 	}
 
 	silence3 := silencer.Silence{
-		mustTypeMatchers(cli.TypeMatchers([]labels.Matcher{
+		Matchers: mustTypeMatchers(cli.TypeMatchers([]labels.Matcher{
 			mustParseMatcher(labels.ParseMatcher("alertname=test1")),
 		})),
-		now,
-		time.Minute,
-		"other comment",
-		"other author",
+		StartAt:   now,
+		Duration:  time.Minute,
+		Comment:   "other comment",
+		CreatedBy: "other author",
+	}
+
+	type expectedWatchedMaintenance struct {
+		Maintenance silencer.Maintenance
+		Next        time.Time
+		IsActive    bool
+		Window      silencer.Window
+		// RecoveredSilence is comment of injected silence, which stays silence of maintenance
+		RecoveredSilence string
 	}
 
 	testCases := []struct {
@@ -71,7 +83,7 @@ func TestMaintenanceService(t *testing.T) {
 		injectSilences              []silencer.Silence
 		maintenances                []silencer.YamlMaintenance
 		expectedSilenceComments     []string
-		expectedWatchedMaintenances []silencer.WatchedMaintenance
+		expectedWatchedMaintenances []expectedWatchedMaintenance
 	}{
 		{
 			name: "two active silences in alertmanager and two maintenances, one of which associated with silence. " +
@@ -83,16 +95,18 @@ func TestMaintenanceService(t *testing.T) {
 				maintenance1, maintenance2,
 			},
 			expectedSilenceComments: []string{silence1.Comment, silence3.Comment},
-			expectedWatchedMaintenances: []silencer.WatchedMaintenance{
+			expectedWatchedMaintenances: []expectedWatchedMaintenance{
 				{
-					m1,
-					m1.Schedule.Next(time.Now()),
-					true,
+					Maintenance:      m1,
+					Next:             m1.Schedule.Next(now),
+					IsActive:         true,
+					Window:           silencer.Window{StartAt: windowStart, EndAt: windowStart.Add(m1.Duration)},
+					RecoveredSilence: silence1.Comment,
 				},
 				{
-					m2,
-					m2.Schedule.Next(time.Now()),
-					false,
+					Maintenance: m2,
+					Next:        m2.Schedule.Next(now),
+					IsActive:    false,
 				},
 			},
 		},
@@ -108,19 +122,24 @@ func TestMaintenanceService(t *testing.T) {
 			)
 			ctx := context.Background()
 
+			injectedIDs := make(map[string]silencer.ActiveSilenceID)
 			for _, s := range tc.injectSilences {
-				_, err := silenceService.Add(ctx, s)
+				id, err := silenceService.Add(ctx, s)
 				if err != nil {
 					t.Fatal(err)
 				}
+				injectedIDs[s.Comment] = id
 			}
 
 			logger := logrus.New()
 			maintenanceService := silencer.NewMaintenanceService(
-				"maintenance service",
+				silencer.Instance{},
 				silencer.MustMaintenances(silencer.ParseMaintenances(tc.maintenances)),
-				silencer.NewActiveMaintenanceStorage(),
-				silenceService,
+				0,
+				0,
+				[]silencer.Target{silencer.NewTarget(silencer.DefaultTargetName, silenceService)},
+				silencer.NewMemoryStateStore(),
+				silencer.NewMemoryHistory(clockMock),
 				clockMock,
 				logger,
 			)
@@ -138,7 +157,28 @@ func TestMaintenanceService(t *testing.T) {
 			// alertmanager api does not guarantee order of silences
 			sort.Strings(comments)
 			assert.Equal(t, tc.expectedSilenceComments, comments)
-			assert.Equal(t, tc.expectedWatchedMaintenances, maintenanceService.WatchedMaintenances())
+
+			watchedMaintenances := maintenanceService.WatchedMaintenances()
+			if !assert.Len(t, watchedMaintenances, len(tc.expectedWatchedMaintenances)) {
+				return
+			}
+			for i, expected := range tc.expectedWatchedMaintenances {
+				actual := watchedMaintenances[i]
+				assert.Equal(t, expected.Maintenance.Hash, actual.Maintenance.Hash)
+				assert.True(t, expected.Next.Equal(actual.Next), "expected next %s, got %s", expected.Next, actual.Next)
+				assert.Equal(t, expected.IsActive, actual.IsActive)
+				assert.True(t, expected.Window.StartAt.Equal(actual.Window.StartAt))
+				assert.True(t, expected.Window.EndAt.Equal(actual.Window.EndAt))
+
+				if !assert.Len(t, actual.Targets, 1) {
+					continue
+				}
+				assert.Equal(t, silencer.DefaultTargetName, actual.Targets[0].Name)
+				assert.Equal(t, expected.IsActive, actual.Targets[0].IsActive)
+				if expected.RecoveredSilence != "" {
+					assert.Equal(t, injectedIDs[expected.RecoveredSilence], actual.Targets[0].SilenceID)
+				}
+			}
 		})
 	}
 }